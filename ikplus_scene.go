@@ -0,0 +1,563 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"log"
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/colornames"
+
+	"go-dom-intro/internal/bitmapfont"
+	"go-dom-intro/internal/demo"
+	"go-dom-intro/internal/scrollmarkup"
+)
+
+var _ demo.Scene = (*IKPlusScene)(nil)
+
+// ScrollText scrolls a slice of pre-tokenized scrollmarkup.Op across canvas,
+// reading glyphs from font (and inline sprites from sprites) and honoring
+// each Op's color/wobble/speed/pause styling as it crosses the leading edge.
+type ScrollText struct {
+	canvas  *ebiten.Image
+	font    *bitmapfont.Font
+	sprites map[string]*ebiten.Image
+	ops     []scrollmarkup.Op
+
+	// cumWidth[i] is the unscaled pixel width of ops[:i]; cumWidth[len(ops)]
+	// is the full text width, cached here since Ops rarely change.
+	cumWidth []float64
+	width    float64
+
+	speed  float64
+	offset float64
+	scaleX float64
+	scaleY float64
+
+	lastLead       int
+	pauseRemaining int
+}
+
+// IKPlusScene is the original "Dom intro" remake: the IK+ logo, copper/raster
+// bars, an infinite starfield, and the four-size scroller.
+type IKPlusScene struct {
+	game *Game
+	rng  *rand.Rand
+
+	starsImage *ebiten.Image
+	logoImage  *ebiten.Image
+	scrollRast *ebiten.Image
+	backRast   *ebiten.Image
+	font       *bitmapfont.Font
+	sprites    map[string]*ebiten.Image
+
+	scrollCanvas1 *ebiten.Image
+	scrollCanvas2 *ebiten.Image
+	scrollCanvas3 *ebiten.Image
+	scrollCanvas4 *ebiten.Image
+	offScroll     *ebiten.Image
+	mergeCanvas   *ebiten.Image
+
+	scrollText1 *ScrollText
+	scrollText2 *ScrollText
+	scrollText3 *ScrollText
+	scrollText4 *ScrollText
+
+	stop     int
+	vbl      int
+	posY     float64
+	posY2    float64
+	actSize  int
+	spinc    float64
+	infStars [8][4]float64
+
+	fullText    string
+	fullOps     []scrollmarkup.Op
+	fontChanges []scrollmarkup.SizeChange
+	totalGlyphs int
+}
+
+func newIKPlusScene(game *Game) *IKPlusScene {
+	s := &IKPlusScene{
+		game: game,
+		rng:  rand.New(rand.NewSource(game.seed)),
+
+		scrollCanvas1: ebiten.NewImage(640, 32),
+		scrollCanvas2: ebiten.NewImage(640, 64),
+		scrollCanvas3: ebiten.NewImage(640, 128),
+		scrollCanvas4: ebiten.NewImage(640, 384),
+		offScroll:     ebiten.NewImage(640, 400),
+		mergeCanvas:   ebiten.NewImage(640, 400),
+
+		stop:    1,
+		posY2:   200,
+		actSize: 0,
+		spinc:   1,
+	}
+
+	s.loadAssets()
+
+	s.fullText = s.getFullText()
+	s.fullOps = scrollmarkup.Parse(s.fullText)
+	s.fontChanges = scrollmarkup.SizeChanges(s.fullOps)
+	s.totalGlyphs = len(s.fullOps)
+
+	s.scrollText1 = s.newScrollText(s.scrollCanvas1, s.font, 1.0, 1.0, scrollmarkup.Rebuild(s.fullOps, 0))
+	s.scrollText2 = s.newScrollText(s.scrollCanvas2, s.font, 2.0, 2.0, scrollmarkup.Rebuild(s.fullOps, 1))
+	s.scrollText3 = s.newScrollText(s.scrollCanvas3, s.font, 4.0, 4.0, scrollmarkup.Rebuild(s.fullOps, 2))
+	s.scrollText4 = s.newScrollText(s.scrollCanvas4, s.font, 8.0, 12.0, scrollmarkup.Rebuild(s.fullOps, 3))
+
+	s.setSpeed()
+
+	for i := 0; i < 8; i++ {
+		s.infStars[i][0] = math.Round(s.rng.Float64()*9) * 64
+		s.infStars[i][1] = math.Round(s.rng.Float64() * 354)
+		s.infStars[i][2] = math.Round(s.rng.Float64()*4) + 4
+		s.infStars[i][3] = math.Round(s.rng.Float64() * 10)
+	}
+
+	return s
+}
+
+func (s *IKPlusScene) loadAssets() {
+	s.starsImage = loadImage("rep_stars.png")
+	s.logoImage = loadImage("rep_ik+_logo.png")
+	s.scrollRast = loadImage("rep_ik+_rast1.png")
+	s.backRast = loadImage("rep_ik+_rast2.png")
+
+	// All four scroller sizes share one atlas and just scale it differently
+	// (scrollText4 non-uniformly: 8x width, 12x height).
+	font, err := bitmapfont.Load(assets, "assets/rep_ik+_font.json")
+	if err != nil {
+		log.Printf("Failed to load scroller font: %v", err)
+	}
+	s.font = font
+
+	s.sprites = map[string]*ebiten.Image{
+		"logo": s.logoImage,
+	}
+}
+
+func (s *IKPlusScene) newScrollText(canvas *ebiten.Image, font *bitmapfont.Font, scaleX, scaleY float64, ops []scrollmarkup.Op) *ScrollText {
+	st := &ScrollText{
+		canvas:   canvas,
+		font:     font,
+		sprites:  s.sprites,
+		ops:      ops,
+		scaleX:   scaleX,
+		scaleY:   scaleY,
+		offset:   float64(canvas.Bounds().Dx()),
+		lastLead: -1,
+	}
+	if font != nil {
+		st.cumWidth = cumulativeWidth(font, s.sprites, ops)
+		st.width = st.cumWidth[len(st.cumWidth)-1]
+	}
+	return st
+}
+
+// opAdvance returns how far the cursor moves past op, given the previously
+// drawn rune for kerning purposes. Sprites advance by their own image width,
+// falling back to a glyph cell's width if the named sprite isn't registered.
+func opAdvance(font *bitmapfont.Font, sprites map[string]*ebiten.Image, op scrollmarkup.Op, prev rune) int {
+	if op.Kind == scrollmarkup.OpSprite {
+		if img, ok := sprites[op.Sprite]; ok {
+			return img.Bounds().Dx()
+		}
+		return font.GlyphWidth()
+	}
+	return font.Advance(prev, op.Rune)
+}
+
+// cumulativeWidth returns a len(ops)+1 slice where cumWidth[i] is the
+// unscaled pixel width of ops[:i], so ScrollText can look up any op's
+// position or measure the whole text without re-walking it every frame.
+func cumulativeWidth(font *bitmapfont.Font, sprites map[string]*ebiten.Image, ops []scrollmarkup.Op) []float64 {
+	cum := make([]float64, len(ops)+1)
+	var prev rune
+	for i, op := range ops {
+		cum[i+1] = cum[i] + float64(opAdvance(font, sprites, op, prev))
+		if op.Kind == scrollmarkup.OpGlyph {
+			prev = op.Rune
+		} else {
+			prev = 0
+		}
+	}
+	return cum
+}
+
+// glyphIndexAtWidth returns how many ops fit before the cumulative width
+// (scaled by scaleX) reaches target.
+func (st *ScrollText) glyphIndexAtWidth(target float64) int {
+	for i, w := range st.cumWidth {
+		if w*st.scaleX >= target {
+			return i
+		}
+	}
+	return len(st.ops)
+}
+
+func (s *IKPlusScene) getFullText() string {
+	spc0 := "                 "
+	spc1 := "         "
+	spc2 := "     "
+	spc3 := "   "
+
+	text := "          THE UNION IS PROUD TO PRESENT YOU :" + spc0 + "^Cs2;INTERNATIONAL KARATE PLUS" + spc2 + "^Cs0;CRACKED  BY" + spc0 + "^Cs3;DOM AND CORWIN" + spc3 + "^Cs1;FROM THE" + spc1 + "^Cs3;REPLICANTS AND DMA" + spc3
+	text += "^Cs1; PRESS F1-F5 AND SEE (IF YOU CAN !!!!) AND LIST..........    A SPECIAL HI TO WILD-XEROX OR RANK-COPPER MY MASTER!!!!!ARF.... HEEEUUUU JUST A LITTLE QUESTION : WHO HAVE" + spc1
+	text += "^Cs2;BARBARIAN 2 ????????" + spc2 + "^Cs1;RRRRHHHHHAAAAAAAAAAA!!!!!! ANYBODY ????? I NEED BLOOD RRRHHAAAA!!!!! NEED HEAD !!!!! OOOUUUIIIINNNN I WEEP .. I CRY...... I RAVE , I'M DELIRIOUS I'M CAUGHT IN THE ACT-HANDED!!!!!!!" + spc1
+	text += "^Cs0;OK KO I STOP, I RESET, I BREAK, I DRINK,I FLY, I CR...-CR... HIHIHI FINALLY I SAY :" + spc0 + "^Cs3;SHEAT" + spc3 + "    ^Cs2;HEY HAVE-YOU CANAL PLUS??????????    WHAT ???????    I SAY CANAL PLUS    BORDEL !! (IN FRENCH)"
+	text += " YOU DON'T HAVE !!!! BUY THIS AND YOU WILL SEE MY MASTER : I NAME : RANK-COOPER ARF ARF HE TURN ONE'S BACK ON THE CAMERA    OOOUUFF!!!HIHI GGGGGGGGGOOOOOOOOODDDDDDDDD" + spc2 + "^Cs1;IT'S ALL FOR DAY......" + spc1
+	text += "^Cs0;REMEMBER YOU BARBARIAN 2 AND CANAL PLUS AND MY MASTER OF COURSE........ HI TO : ALL MEMBERS OF DMA(ESPECIALLY LOCKBUSTER FOR ORIGINAL), DELTA FORCE, TEX, BLADE RUNNERS, CHON-CHON, ALDO, ST-CONNEXION, THE HOBBIT BROTHERS, "
+	text += "ABC 85, THE BARBARIANS......." + spc0
+	text += "^Cs0;              "
+
+	return text
+}
+
+func (s *IKPlusScene) setSpeed() {
+	switch s.actSize {
+	case 0:
+		s.scrollText1.speed = 8 * s.spinc
+		s.scrollText2.speed = 16 * s.spinc
+		s.scrollText3.speed = 32 * s.spinc
+		s.scrollText4.speed = 64 * s.spinc
+	case 1:
+		s.scrollText1.speed = 4 * s.spinc
+		s.scrollText2.speed = 8 * s.spinc
+		s.scrollText3.speed = 16 * s.spinc
+		s.scrollText4.speed = 32 * s.spinc
+	case 2:
+		s.scrollText1.speed = 2 * s.spinc
+		s.scrollText2.speed = 4 * s.spinc
+		s.scrollText3.speed = 8 * s.spinc
+		s.scrollText4.speed = 16 * s.spinc
+	case 3:
+		s.scrollText1.speed = 1 * s.spinc
+		s.scrollText2.speed = 2 * s.spinc
+		s.scrollText3.speed = 4 * s.spinc
+		s.scrollText4.speed = 8 * s.spinc
+	}
+
+	mod := s.audioSpeedModulation()
+	s.scrollText1.speed *= mod
+	s.scrollText2.speed *= mod
+	s.scrollText3.speed *= mod
+	s.scrollText4.speed *= mod
+}
+
+// audioSpeedModulation turns the current audio frame's summed channel
+// volume (0..45) into a +/-15% scroll speed multiplier, so the text pulses
+// with the music instead of scrolling at a flat rate.
+func (s *IKPlusScene) audioSpeedModulation() float64 {
+	f := s.game.audioFrame
+	sum := f.Volumes[0] + f.Volumes[1] + f.Volumes[2]
+	return 0.85 + float64(sum)/45*0.3
+}
+
+// audioPaletteTint maps each AY channel's volume onto a mild per-channel
+// color boost for the plasma/raster bars, so the palette visibly leans
+// toward whichever voice is loudest.
+func (s *IKPlusScene) audioPaletteTint() (r, gr, b float64) {
+	f := s.game.audioFrame
+	r = 1 + float64(f.Volumes[0])/15*0.35
+	gr = 1 + float64(f.Volumes[1])/15*0.35
+	b = 1 + float64(f.Volumes[2])/15*0.35
+	return
+}
+
+// starSpawnThreshold lowers the infStars respawn threshold (normally 9) when
+// the music is louder or has just hit a beat, so stars burst in time with
+// the tune instead of respawning at a constant rate.
+func (s *IKPlusScene) starSpawnThreshold() float64 {
+	f := s.game.audioFrame
+	sum := f.Volumes[0] + f.Volumes[1] + f.Volumes[2]
+	threshold := 9 - float64(sum)/45*4
+	if f.Beat {
+		threshold -= 2
+	}
+	if threshold < 3 {
+		threshold = 3
+	}
+	return threshold
+}
+
+// draw advances the scroll by one tick and renders it, honoring whichever
+// Op is currently at the leading edge: its Style.SpeedMul scales how far the
+// scroll moves, and a positive PauseFrames freezes it for that many ticks.
+func (st *ScrollText) draw(tick float64) {
+	if st.width <= 0 {
+		st.drawAtOffset(st.offset, tick)
+		return
+	}
+
+	lead := st.glyphIndexAtWidth(-st.offset)
+	if lead != st.lastLead {
+		st.lastLead = lead
+		if lead < len(st.ops) && st.ops[lead].PauseFrames > 0 {
+			st.pauseRemaining = st.ops[lead].PauseFrames
+		}
+	}
+
+	speedMul := 1.0
+	if lead < len(st.ops) && st.ops[lead].Style.SpeedMul != 0 {
+		speedMul = st.ops[lead].Style.SpeedMul
+	}
+
+	if st.pauseRemaining > 0 {
+		st.pauseRemaining--
+	} else {
+		st.offset -= st.speed * speedMul
+		totalWidth := st.width * st.scaleX
+		if st.offset <= -totalWidth {
+			st.offset += totalWidth + float64(st.canvas.Bounds().Dx())
+		}
+	}
+
+	st.drawAtOffset(st.offset, tick)
+}
+
+// drawAt positions the scroll at an explicit offset without advancing it,
+// used to keep scrollText2-4 locked to scrollText1's lead.
+func (st *ScrollText) drawAt(offset, tick float64) {
+	st.offset = offset
+	st.drawAtOffset(st.offset, tick)
+}
+
+func (st *ScrollText) drawAtOffset(offset, tick float64) {
+	st.canvas.Clear() // Clear to transparent
+
+	if st.font == nil {
+		return
+	}
+
+	width := float64(st.canvas.Bounds().Dx())
+	for i, op := range st.ops {
+		x := offset + st.cumWidth[i]*st.scaleX
+		if x >= width {
+			break
+		}
+		if op.Hidden {
+			continue
+		}
+
+		y := 0.0
+		if op.Style.WobbleAmp != 0 {
+			y = op.Style.WobbleAmp * math.Sin(tick*op.Style.WobbleFreq+float64(i))
+		}
+
+		drawOp := &ebiten.DrawImageOptions{}
+		drawOp.GeoM.Scale(st.scaleX, st.scaleY)
+		drawOp.GeoM.Translate(x, y)
+		drawOp.Filter = ebiten.FilterNearest
+		if op.Style.Color != nil {
+			r, g, b, _ := op.Style.Color.RGBA()
+			drawOp.ColorM.Scale(float64(r)/0xffff, float64(g)/0xffff, float64(b)/0xffff, 1)
+		}
+
+		switch op.Kind {
+		case scrollmarkup.OpGlyph:
+			if g, ok := st.font.Glyph(op.Rune); ok {
+				st.canvas.DrawImage(st.font.Image(g), drawOp)
+			}
+		case scrollmarkup.OpSprite:
+			if img, ok := st.sprites[op.Sprite]; ok {
+				st.canvas.DrawImage(img, drawOp)
+			}
+		}
+	}
+}
+
+func (s *IKPlusScene) updateActSizeFromScroll() {
+	if s.totalGlyphs == 0 || s.font == nil {
+		return
+	}
+
+	st := s.scrollText1
+	switch s.actSize {
+	case 1:
+		st = s.scrollText2
+	case 2:
+		st = s.scrollText3
+	case 3:
+		st = s.scrollText4
+	}
+	if st == nil {
+		return
+	}
+
+	target := -st.offset + float64(st.canvas.Bounds().Dx())
+	glyphPos := st.glyphIndexAtWidth(target)
+	if glyphPos >= s.totalGlyphs {
+		glyphPos = s.totalGlyphs - 1
+	}
+
+	size := 0
+	for _, change := range s.fontChanges {
+		if change.Position <= glyphPos {
+			size = change.Size
+		} else {
+			break
+		}
+	}
+	if size != s.actSize {
+		s.actSize = size
+		s.setSpeed()
+	}
+}
+
+func (s *IKPlusScene) Enter() {}
+
+func (s *IKPlusScene) Update(dt float64) {
+	if ebiten.IsKeyPressed(ebiten.KeyF1) {
+		if s.spinc < 4 && s.spinc >= 1 {
+			s.spinc++
+		} else if s.spinc == 0.5 {
+			s.spinc = 1
+		} else if s.spinc == 0.25 {
+			s.spinc = 0.5
+		}
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyF2) {
+		if s.spinc > 1 {
+			s.spinc--
+		} else if s.spinc == 0.5 {
+			s.spinc = 0.25
+		} else if s.spinc == 1 {
+			s.spinc = 0.5
+		}
+	}
+	// Re-applied every frame (not just on F1/F2) so scrollText speeds keep
+	// tracking audioSpeedModulation as the music's channel volumes move.
+	s.setSpeed()
+
+	s.vbl++
+
+	if s.vbl%2 == 0 {
+		s.posY += 4
+		if s.posY >= 654 {
+			s.posY = 0
+		}
+		s.posY2 -= 2
+		if s.posY2 <= 0 {
+			s.posY2 = 200
+		}
+	}
+
+	spawnThreshold := s.starSpawnThreshold()
+	for i := 0; i < 8; i++ {
+		s.infStars[i][3] += 1 / s.infStars[i][2]
+		if s.infStars[i][3] >= spawnThreshold {
+			s.infStars[i][0] = math.Round(s.rng.Float64()*9) * 64
+			s.infStars[i][1] = math.Round(s.rng.Float64() * 354)
+			s.infStars[i][2] = math.Round(s.rng.Float64()*4) + 4
+			s.infStars[i][3] = 0
+		}
+	}
+
+	if s.scrollText1 != nil {
+		tick := float64(s.vbl)
+		s.scrollText1.draw(tick)
+		baseOffset := s.scrollText1.offset
+		baseWidth := float64(s.scrollText1.canvas.Bounds().Dx())
+		if s.scrollText2 != nil {
+			s.scrollText2.drawAt(baseOffset*s.scrollText2.scaleX+(1-s.scrollText2.scaleX)*baseWidth, tick)
+		}
+		if s.scrollText3 != nil {
+			s.scrollText3.drawAt(baseOffset*s.scrollText3.scaleX+(1-s.scrollText3.scaleX)*baseWidth, tick)
+		}
+		if s.scrollText4 != nil {
+			s.scrollText4.drawAt(baseOffset*s.scrollText4.scaleX+(1-s.scrollText4.scaleX)*baseWidth, tick)
+		}
+	}
+	s.updateActSizeFromScroll()
+}
+
+func (s *IKPlusScene) Draw(screen *ebiten.Image) {
+	if s.stop <= 0 {
+		return
+	}
+	screen.Fill(colornames.Black)
+
+	rTint, gTint, bTint := s.audioPaletteTint()
+
+	if s.backRast != nil {
+		for j := 0; j < 11; j++ {
+			sy := int(s.posY) + j*4
+			drawPartTinted(screen, s.backRast, 0, 60+2+j*36, 0, sy, 1, 36, 1, 0, 768, 1, rTint, gTint, bTint)
+		}
+	}
+
+	if s.mergeCanvas != nil {
+		s.mergeCanvas.Fill(color.Transparent)
+	}
+
+	if s.scrollRast != nil {
+		drawPartTinted(s.mergeCanvas, s.scrollRast, 0, int(s.posY2)-200, 0, 0, 2, 200, 1, 0, 320, 1, rTint, gTint, bTint)
+		drawPartTinted(s.mergeCanvas, s.scrollRast, 0, int(s.posY2), 0, 0, 2, 200, 1, 0, 320, 1, rTint, gTint, bTint)
+		drawPartTinted(s.mergeCanvas, s.scrollRast, 0, int(s.posY2)+200, 0, 0, 2, 200, 1, 0, 320, 1, rTint, gTint, bTint)
+	}
+
+	if s.offScroll != nil {
+		s.offScroll.Fill(color.Transparent)
+	}
+
+	switch s.actSize {
+	case 0:
+		if s.scrollCanvas1 != nil {
+			for j := 0; j < 11; j++ {
+				drawPart(s.offScroll, s.scrollCanvas1, 0, 2+j*36, 0, 0, 640, 32, 1, 0, 1, 1)
+			}
+		}
+	case 1:
+		if s.scrollCanvas2 != nil {
+			for j := 0; j < 6; j++ {
+				drawPart(s.offScroll, s.scrollCanvas2, 0, 2+j*66, 0, 0, 640, 64, 1, 0, 1, 1)
+			}
+		}
+	case 2:
+		if s.scrollCanvas3 != nil {
+			drawPart(s.offScroll, s.scrollCanvas3, 0, 0, 0, 0, 640, 128, 1, 0, 1, 1)
+			drawPart(s.offScroll, s.scrollCanvas3, 0, 134, 0, 0, 640, 128, 1, 0, 1, 1)
+			drawPart(s.offScroll, s.scrollCanvas3, 0, 268, 0, 0, 640, 128, 1, 0, 1, 1)
+		}
+	case 3:
+		if s.scrollCanvas4 != nil {
+			drawPart(s.offScroll, s.scrollCanvas4, 0, 4, 0, 0, 640, 384, 1, 0, 1, 1)
+		}
+	}
+
+	if s.mergeCanvas != nil && s.offScroll != nil {
+		op := &ebiten.DrawImageOptions{}
+		op.CompositeMode = ebiten.CompositeModeDestinationIn
+		op.GeoM.Translate(0, 2)
+		s.mergeCanvas.DrawImage(s.offScroll, op)
+		if s.mergeCanvas.Bounds().Dy() >= 2 {
+			top := s.mergeCanvas.SubImage(image.Rect(0, 0, s.mergeCanvas.Bounds().Dx(), 2)).(*ebiten.Image)
+			top.Clear()
+		}
+	}
+
+	if s.mergeCanvas != nil {
+		op2 := &ebiten.DrawImageOptions{}
+		op2.GeoM.Translate(64, 60)
+		screen.DrawImage(s.mergeCanvas, op2)
+	}
+
+	if s.logoImage != nil {
+		opLogo := &ebiten.DrawImageOptions{}
+		opLogo.GeoM.Translate(64, 60+36)
+		screen.DrawImage(s.logoImage, opLogo)
+	}
+
+	if s.starsImage != nil {
+		for i := 0; i < 8; i++ {
+			tile := int(math.Round(s.infStars[i][3]))
+			drawTile(screen, s.starsImage, tile, 64+int(s.infStars[i][0]), 60+int(s.infStars[i][1]), 64, 46, 1, 0, 1, 1)
+		}
+	}
+}
+
+func (s *IKPlusScene) Exit() {}
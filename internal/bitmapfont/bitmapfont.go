@@ -0,0 +1,198 @@
+// Package bitmapfont loads bitmap font atlases described by a small JSON
+// sidecar file, so scrollers and other text-drawing code can support
+// variable-width glyphs, lowercase, digits, punctuation, and multi-page
+// atlases without a hardcoded codepoint-to-tile formula like the old
+// tileIndex function.
+package bitmapfont
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/png"
+	"io"
+	"io/fs"
+	"path"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Glyph is one character's location within its page image, plus how far the
+// cursor should advance after drawing it.
+type Glyph struct {
+	Page    int
+	Rect    image.Rectangle
+	Advance int
+}
+
+// Font is a loaded bitmap font: one or more atlas pages, a codepoint-to-Glyph
+// map, and optional per-pair kerning adjustments.
+type Font struct {
+	pages       []*ebiten.Image
+	glyphs      map[rune]Glyph
+	kerning     map[[2]rune]int
+	glyphWidth  int
+	glyphHeight int
+	baseline    int
+}
+
+// descriptor is the on-disk JSON sidecar format. A page's tiles are laid out
+// left-to-right, top-to-bottom in a grid of glyphWidth x glyphHeight cells,
+// Columns wide; codepoints assigns each successive tile, in order, to the
+// rune at the same position in its Codepoints string, so an atlas only needs
+// to contain the glyphs it actually uses.
+type descriptor struct {
+	GlyphWidth  int              `json:"glyph_width"`
+	GlyphHeight int              `json:"glyph_height"`
+	Columns     int              `json:"columns"`
+	Baseline    int              `json:"baseline"`
+	Pages       []pageDescriptor `json:"pages"`
+	Advances    map[string]int   `json:"advances"` // codepoint -> custom advance, overrides GlyphWidth
+	Kerning     map[string]int   `json:"kerning"`  // two-rune codepoint pair, e.g. "AV" -> -2
+}
+
+type pageDescriptor struct {
+	Image      string `json:"image"`
+	Codepoints string `json:"codepoints"`
+}
+
+// Load reads the JSON descriptor at descriptorPath from fsys and decodes
+// each page image it references, resolved relative to the descriptor's own
+// directory.
+func Load(fsys fs.FS, descriptorPath string) (*Font, error) {
+	raw, err := fs.ReadFile(fsys, descriptorPath)
+	if err != nil {
+		return nil, fmt.Errorf("bitmapfont: read descriptor %s: %w", descriptorPath, err)
+	}
+
+	var desc descriptor
+	if err := json.Unmarshal(raw, &desc); err != nil {
+		return nil, fmt.Errorf("bitmapfont: parse descriptor %s: %w", descriptorPath, err)
+	}
+	if desc.Columns <= 0 {
+		return nil, fmt.Errorf("bitmapfont: descriptor %s has no columns", descriptorPath)
+	}
+
+	f := &Font{
+		glyphs:      map[rune]Glyph{},
+		kerning:     map[[2]rune]int{},
+		glyphWidth:  desc.GlyphWidth,
+		glyphHeight: desc.GlyphHeight,
+		baseline:    desc.Baseline,
+	}
+
+	dir := path.Dir(descriptorPath)
+	for pageIdx, pd := range desc.Pages {
+		img, err := loadPageImage(fsys, path.Join(dir, pd.Image))
+		if err != nil {
+			return nil, fmt.Errorf("bitmapfont: %s: %w", descriptorPath, err)
+		}
+		f.pages = append(f.pages, img)
+
+		// range over []rune, not the string directly: Codepoints is indexed
+		// by sequential glyph position, and ranging over a string yields
+		// byte offsets, which only happen to line up for ASCII-only content.
+		for tile, r := range []rune(pd.Codepoints) {
+			row := tile / desc.Columns
+			col := tile % desc.Columns
+			advance := desc.GlyphWidth
+			if a, ok := desc.Advances[string(r)]; ok {
+				advance = a
+			}
+			f.glyphs[r] = Glyph{
+				Page: pageIdx,
+				Rect: image.Rect(
+					col*desc.GlyphWidth, row*desc.GlyphHeight,
+					(col+1)*desc.GlyphWidth, (row+1)*desc.GlyphHeight,
+				),
+				Advance: advance,
+			}
+		}
+	}
+
+	for pair, adjust := range desc.Kerning {
+		runes := []rune(pair)
+		if len(runes) != 2 {
+			continue
+		}
+		f.kerning[[2]rune{runes[0], runes[1]}] = adjust
+	}
+
+	return f, nil
+}
+
+func loadPageImage(fsys fs.FS, name string) (*ebiten.Image, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("open page %s: %w", name, err)
+	}
+	defer file.Close()
+
+	b, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("read page %s: %w", name, err)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("decode page %s: %w", name, err)
+	}
+
+	return ebiten.NewImageFromImage(img), nil
+}
+
+// Glyph reports the tile and advance for r, or ok=false if the font has no
+// glyph for it.
+func (f *Font) Glyph(r rune) (Glyph, bool) {
+	g, ok := f.glyphs[r]
+	return g, ok
+}
+
+// Image returns the subimage backing g, ready to draw.
+func (f *Font) Image(g Glyph) *ebiten.Image {
+	return f.pages[g.Page].SubImage(g.Rect).(*ebiten.Image)
+}
+
+// GlyphWidth and GlyphHeight return the atlas cell size all glyphs share,
+// regardless of any per-glyph advance override.
+func (f *Font) GlyphWidth() int  { return f.glyphWidth }
+func (f *Font) GlyphHeight() int { return f.glyphHeight }
+
+// Baseline returns the row, measured down from the top of a glyph cell, that
+// glyphs should be vertically aligned against.
+func (f *Font) Baseline() int { return f.baseline }
+
+// Kerning returns the extra (or reduced, if negative) spacing to apply
+// between prev and cur, on top of prev's normal Advance.
+func (f *Font) Kerning(prev, cur rune) int {
+	return f.kerning[[2]rune{prev, cur}]
+}
+
+// Advance returns how far the cursor should move after drawing cur, given
+// the previously drawn rune (0 if cur is first on the line), including any
+// kerning adjustment between the two. Unknown glyphs fall back to
+// GlyphWidth so a missing codepoint still reserves layout space.
+func (f *Font) Advance(prev, cur rune) int {
+	g, ok := f.glyphs[cur]
+	adv := f.glyphWidth
+	if ok {
+		adv = g.Advance
+	}
+	if prev != 0 {
+		adv += f.kerning[[2]rune{prev, cur}]
+	}
+	return adv
+}
+
+// MeasureString returns the total pixel width of s at the font's native
+// scale, applying kerning between consecutive runes.
+func (f *Font) MeasureString(s string) int {
+	width := 0
+	var prev rune
+	for _, r := range s {
+		width += f.Advance(prev, r)
+		prev = r
+	}
+	return width
+}
@@ -0,0 +1,162 @@
+package bitmapfont
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"testing/fstest"
+)
+
+// encodePNG renders a solid w x h image, the simplest page image Load can
+// decode without needing real glyph art.
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode fixture png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestLoadNonASCIICodepoint pins the fix to Load's page-to-glyph mapping: it
+// must assign tiles by rune index, not by byte offset, or a multi-byte
+// codepoint after an ASCII one shifts every glyph that follows it.
+func TestLoadNonASCIICodepoint(t *testing.T) {
+	desc := descriptor{
+		GlyphWidth:  4,
+		GlyphHeight: 4,
+		Columns:     2,
+		Baseline:    3,
+		Pages: []pageDescriptor{
+			{Image: "page0.png", Codepoints: "aé0"},
+		},
+		Advances: map[string]int{"a": 5},
+		Kerning:  map[string]int{"aé": -1},
+	}
+	raw, err := json.Marshal(desc)
+	if err != nil {
+		t.Fatalf("marshal descriptor: %v", err)
+	}
+
+	fsys := fstest.MapFS{
+		"font.json": {Data: raw},
+		"page0.png": {Data: encodePNG(t, 8, 8)},
+	}
+
+	f, err := Load(fsys, "font.json")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	ga, ok := f.Glyph('a')
+	if !ok {
+		t.Fatalf("Glyph('a'): not found")
+	}
+	if ga.Rect != image.Rect(0, 0, 4, 4) {
+		t.Errorf("Glyph('a').Rect = %v, want (0,0)-(4,4)", ga.Rect)
+	}
+	if ga.Advance != 5 {
+		t.Errorf("Glyph('a').Advance = %d, want 5 (custom)", ga.Advance)
+	}
+
+	ge, ok := f.Glyph('é')
+	if !ok {
+		t.Fatalf("Glyph('é'): not found")
+	}
+	// 'é' is tile index 1 (second rune of Codepoints). It takes two bytes,
+	// so the '0' checked below is at rune index 2 but byte offset 3.
+	if ge.Rect != image.Rect(4, 0, 8, 4) {
+		t.Errorf("Glyph('é').Rect = %v, want (4,0)-(8,4)", ge.Rect)
+	}
+	if ge.Advance != 4 {
+		t.Errorf("Glyph('é').Advance = %d, want 4 (default GlyphWidth)", ge.Advance)
+	}
+
+	g0, ok := f.Glyph('0')
+	if !ok {
+		t.Fatalf("Glyph('0'): not found")
+	}
+	if g0.Rect != image.Rect(0, 4, 4, 8) {
+		t.Errorf("Glyph('0').Rect = %v, want (0,4)-(4,8) (tile 2 wraps to row 1; tile 3, its byte offset, would be (4,4)-(8,8))", g0.Rect)
+	}
+
+	if k := f.Kerning('a', 'é'); k != -1 {
+		t.Errorf("Kerning('a', 'é') = %d, want -1", k)
+	}
+}
+
+// TestLoadMultiPage checks that each page's codepoints are assigned to that
+// page's index rather than all landing on page 0.
+func TestLoadMultiPage(t *testing.T) {
+	desc := descriptor{
+		GlyphWidth:  4,
+		GlyphHeight: 4,
+		Columns:     1,
+		Pages: []pageDescriptor{
+			{Image: "page0.png", Codepoints: "A"},
+			{Image: "page1.png", Codepoints: "B"},
+		},
+	}
+	raw, err := json.Marshal(desc)
+	if err != nil {
+		t.Fatalf("marshal descriptor: %v", err)
+	}
+
+	fsys := fstest.MapFS{
+		"font.json": {Data: raw},
+		"page0.png": {Data: encodePNG(t, 4, 4)},
+		"page1.png": {Data: encodePNG(t, 4, 4)},
+	}
+
+	f, err := Load(fsys, "font.json")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	gA, ok := f.Glyph('A')
+	if !ok || gA.Page != 0 {
+		t.Errorf("Glyph('A') = %+v, ok=%v, want Page 0", gA, ok)
+	}
+	gB, ok := f.Glyph('B')
+	if !ok || gB.Page != 1 {
+		t.Errorf("Glyph('B') = %+v, ok=%v, want Page 1", gB, ok)
+	}
+}
+
+func TestAdvanceFallsBackToGlyphWidthForUnknownRune(t *testing.T) {
+	desc := descriptor{
+		GlyphWidth:  6,
+		GlyphHeight: 6,
+		Columns:     1,
+		Pages: []pageDescriptor{
+			{Image: "page0.png", Codepoints: "A"},
+		},
+	}
+	raw, err := json.Marshal(desc)
+	if err != nil {
+		t.Fatalf("marshal descriptor: %v", err)
+	}
+
+	fsys := fstest.MapFS{
+		"font.json": {Data: raw},
+		"page0.png": {Data: encodePNG(t, 6, 6)},
+	}
+
+	f, err := Load(fsys, "font.json")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if adv := f.Advance(0, 'Z'); adv != 6 {
+		t.Errorf("Advance for unmapped rune = %d, want GlyphWidth 6", adv)
+	}
+}
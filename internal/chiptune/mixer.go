@@ -0,0 +1,85 @@
+package chiptune
+
+// sample holds one decoded 8-bit PCM instrument sample, signed and centered
+// on zero, plus its Amiga-style loop points (in sample frames).
+type sample struct {
+	data       []int8
+	loopStart  int
+	loopLength int
+	volume     float64 // 0..1, instrument default volume
+}
+
+func (s *sample) loops() bool { return s.loopLength > 2 }
+
+// trackerChannel is one voice of the software mixer: a sample, a playback
+// position expressed in fractional sample-frames, and the per-row state a
+// pattern row can change (note trigger, volume, panning).
+type trackerChannel struct {
+	smp     *sample
+	pos     float64 // fractional sample position
+	step    float64 // samples advanced per output frame, derived from period/note
+	volume  float64 // 0..1 current channel volume
+	pan     float64 // 0 (left) .. 1 (right)
+	playing bool
+	muted   bool
+}
+
+func (c *trackerChannel) reset(s *sample, step float64, volume float64) {
+	c.smp = s
+	c.pos = 0
+	c.step = step
+	c.volume = volume
+	c.playing = s != nil && len(s.data) > 0
+}
+
+// mix advances every channel by one output frame and returns the mixed
+// (left, right) sample in the -1..1 range.
+func mix(channels []*trackerChannel, soloed int) (float64, float64) {
+	var left, right float64
+	for i, c := range channels {
+		if !c.playing || c.smp == nil {
+			continue
+		}
+		mute := c.muted
+		if soloed >= 0 && soloed != i {
+			mute = true
+		}
+
+		idx := int(c.pos)
+		if idx >= len(c.smp.data) {
+			if c.smp.loops() {
+				c.pos -= float64(len(c.smp.data) - c.smp.loopStart)
+				idx = int(c.pos)
+			} else {
+				c.playing = false
+				continue
+			}
+		}
+		if idx < 0 || idx >= len(c.smp.data) {
+			c.playing = false
+			continue
+		}
+
+		if !mute {
+			v := float64(c.smp.data[idx]) / 128 * c.volume
+			left += v * (1 - c.pan)
+			right += v * c.pan
+		}
+
+		c.pos += c.step
+		if c.smp.loops() && c.pos >= float64(c.smp.loopStart+c.smp.loopLength) {
+			c.pos = float64(c.smp.loopStart) + (c.pos - float64(c.smp.loopStart+c.smp.loopLength))
+		}
+	}
+	return clamp(left), clamp(right)
+}
+
+func clamp(v float64) float64 {
+	if v > 1 {
+		return 1
+	}
+	if v < -1 {
+		return -1
+	}
+	return v
+}
@@ -0,0 +1,104 @@
+package chiptune
+
+import "testing"
+
+// loopingSample returns a sample whose loop covers all of data (loopStart 0,
+// loopLength len(data)). For data longer than 2 frames (the loops()
+// threshold) mix can run well past its natural end without ever stopping.
+func loopingSample(data []int8) *sample {
+	return &sample{data: data, loopStart: 0, loopLength: len(data), volume: 1}
+}
+
+func TestTrackerChannelResetStartsAtZero(t *testing.T) {
+	s := loopingSample([]int8{10, 20, 30, 40})
+	c := &trackerChannel{}
+	c.reset(s, 1, 0.5)
+
+	if !c.playing {
+		t.Fatal("reset with a non-empty sample did not set playing")
+	}
+	if c.pos != 0 {
+		t.Errorf("pos = %v, want 0", c.pos)
+	}
+	if c.volume != 0.5 {
+		t.Errorf("volume = %v, want 0.5", c.volume)
+	}
+}
+
+func TestTrackerChannelResetWithNilSampleStopsPlaying(t *testing.T) {
+	c := &trackerChannel{playing: true}
+	c.reset(nil, 1, 1)
+	if c.playing {
+		t.Error("reset(nil, ...) left playing=true")
+	}
+}
+
+func TestMixWrapsAroundLoopBoundary(t *testing.T) {
+	s := loopingSample([]int8{127, 0, -128, 0})
+	c := &trackerChannel{pan: 0.5}
+	c.reset(s, 1, 1)
+	channels := []*trackerChannel{c}
+
+	// Advancing by exactly one full loop (4 frames of step 1) should land
+	// back at the sample's first frame without ever stopping playback.
+	for i := 0; i < 4; i++ {
+		if !c.playing {
+			t.Fatalf("frame %d: channel stopped before completing a loop", i)
+		}
+		mix(channels, -1)
+	}
+
+	if !c.playing {
+		t.Fatal("channel stopped after wrapping the loop once")
+	}
+	if c.pos != 0 {
+		t.Errorf("pos after one full loop = %v, want 0", c.pos)
+	}
+
+	left, right := mix(channels, -1)
+	wantLeft := float64(127) / 128 * 0.5
+	wantRight := wantLeft
+	if left != wantLeft || right != wantRight {
+		t.Errorf("mix at wrapped frame 0 = (%v, %v), want (%v, %v)", left, right, wantLeft, wantRight)
+	}
+}
+
+func TestMixStopsNonLoopingSampleAtEnd(t *testing.T) {
+	s := &sample{data: []int8{1, 2}, loopLength: 0, volume: 1}
+	c := &trackerChannel{}
+	c.reset(s, 1, 1)
+	channels := []*trackerChannel{c}
+
+	mix(channels, -1) // idx 0 -> pos 1
+	if !c.playing {
+		t.Fatal("channel stopped before reaching the end of a non-looping sample")
+	}
+	mix(channels, -1) // idx 1 -> pos 2
+	if !c.playing {
+		t.Fatal("channel stopped one frame early")
+	}
+	mix(channels, -1) // idx 2, past the end, and not a looping sample
+	if c.playing {
+		t.Error("channel kept playing past a non-looping sample's end")
+	}
+}
+
+func TestMixMutesAllButSoloedChannel(t *testing.T) {
+	s := loopingSample([]int8{127})
+	a := &trackerChannel{pan: 0} // contributes to left only
+	b := &trackerChannel{pan: 1} // contributes to right only
+	a.reset(s, 0, 1)
+	b.reset(s, 0, 1)
+	channels := []*trackerChannel{a, b}
+
+	// Soloing b (index 1) mutes a; a's output would land on left, so left
+	// must stay silent while b's (on right) comes through unmuted.
+	left, right := mix(channels, 1)
+	if left != 0 {
+		t.Errorf("left = %v with channel 0 soloed-out, want 0", left)
+	}
+	want := float64(127) / 128
+	if right != want {
+		t.Errorf("right = %v with channel 1 soloed, want %v", right, want)
+	}
+}
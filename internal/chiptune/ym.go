@@ -0,0 +1,222 @@
+package chiptune
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/olivierh59500/ym-player/pkg/stsound"
+)
+
+// channelFrameRingSize is intentionally small: Update() only ever wants the
+// most recently decoded frame, the ring just avoids a writer/reader mutex on
+// the audio hot path.
+const channelFrameRingSize = 4
+
+// channelFrameRing is a single-producer/single-consumer ring of ChannelFrame
+// snapshots. The audio goroutine (Read) is the sole writer; Game.Update is
+// the sole reader; the write index is the only state shared between them.
+type channelFrameRing struct {
+	frames   [channelFrameRingSize]ChannelFrame
+	writeIdx uint32
+}
+
+func (r *channelFrameRing) push(f ChannelFrame) {
+	next := atomic.LoadUint32(&r.writeIdx) + 1
+	r.frames[next%channelFrameRingSize] = f
+	atomic.StoreUint32(&r.writeIdx, next)
+}
+
+func (r *channelFrameRing) latest() ChannelFrame {
+	idx := atomic.LoadUint32(&r.writeIdx)
+	return r.frames[idx%channelFrameRingSize]
+}
+
+// YMPlayer decodes an Atari ST YM (AY-3-8910 register log) tune via stsound.
+// This is the backend the intro originally hardcoded Game.ymPlayer to.
+type YMPlayer struct {
+	player       *stsound.StSound
+	sampleRate   int
+	buffer       []int16
+	mutex        sync.Mutex
+	position     int64
+	totalSamples int64
+	loop         bool
+	volume       float64
+
+	frames      channelFrameRing
+	lastVolSum  int
+	envPosition int
+}
+
+// NewYMPlayer loads data as a YM tune and prepares it for playback at sampleRate.
+func NewYMPlayer(data []byte, sampleRate int, loop bool) (*YMPlayer, error) {
+	player := stsound.CreateWithRate(sampleRate)
+
+	if err := player.LoadMemory(data); err != nil {
+		player.Destroy()
+		return nil, fmt.Errorf("failed to load YM data: %w", err)
+	}
+
+	player.SetLoopMode(loop)
+
+	info := player.GetInfo()
+	totalSamples := int64(info.MusicTimeInMs) * int64(sampleRate) / 1000
+
+	return &YMPlayer{
+		player:       player,
+		sampleRate:   sampleRate,
+		buffer:       make([]int16, 4096),
+		totalSamples: totalSamples,
+		loop:         loop,
+		volume:       0.5,
+	}, nil
+}
+
+// beatOnsetThreshold is how much the summed channel volume (0..45) must rise
+// between consecutive frames before it counts as a beat/onset.
+const beatOnsetThreshold = 10
+
+// sampleChannelFrame reads the AY-3-8910 register state straight out of
+// stsound right after a Compute call, so the returned frame reflects the chip
+// state at the end of the chunk just rendered into y.buffer (a chunk of up to
+// 4096 samples can span several YM frames). GetRegister only reads one register
+// at a time, so the mixer and per-voice volume/envelope registers are pulled
+// individually rather than as a block.
+func (y *YMPlayer) sampleChannelFrame() ChannelFrame {
+	mixer := y.player.GetRegister(7)
+
+	var frame ChannelFrame
+	volSum := 0
+	for ch := 0; ch < 3; ch++ {
+		vol := y.player.GetRegister(8+ch) & 0x0f
+		frame.Volumes[ch] = vol
+		volSum += vol
+		frame.ToneMix[ch] = mixer&(1<<uint(ch)) == 0
+		frame.NoiseMix[ch] = mixer&(1<<uint(ch+3)) == 0
+	}
+
+	envPeriod := y.player.GetRegister(11) | y.player.GetRegister(12)<<8
+	if envPeriod == 0 {
+		envPeriod = 1
+	}
+	y.envPosition = (y.envPosition + 1) % envPeriod
+	frame.EnvelopePhase = float64(y.envPosition) / float64(envPeriod)
+
+	frame.Beat = volSum-y.lastVolSum >= beatOnsetThreshold
+	y.lastVolSum = volSum
+
+	return frame
+}
+
+// LatestFrame returns the most recently analyzed ChannelFrame, satisfying
+// the chiptune.Analyzer interface.
+func (y *YMPlayer) LatestFrame() ChannelFrame {
+	return y.frames.latest()
+}
+
+func (y *YMPlayer) Read(p []byte) (n int, err error) {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+
+	samplesNeeded := len(p) / 4
+	outBuffer := make([]int16, samplesNeeded*2)
+
+	processed := 0
+	for processed < samplesNeeded {
+		chunkSize := samplesNeeded - processed
+		if chunkSize > len(y.buffer) {
+			chunkSize = len(y.buffer)
+		}
+
+		if !y.player.Compute(y.buffer[:chunkSize], chunkSize) {
+			if !y.loop {
+				for i := processed * 2; i < len(outBuffer); i++ {
+					outBuffer[i] = 0
+				}
+				err = io.EOF
+				break
+			}
+		}
+		y.frames.push(y.sampleChannelFrame())
+
+		for i := 0; i < chunkSize; i++ {
+			sample := int16(float64(y.buffer[i]) * y.volume)
+			outBuffer[(processed+i)*2] = sample
+			outBuffer[(processed+i)*2+1] = sample
+		}
+
+		processed += chunkSize
+		y.position += int64(chunkSize)
+	}
+
+	buf := make([]byte, 0, len(outBuffer)*2)
+	for _, sample := range outBuffer {
+		buf = append(buf, byte(sample), byte(sample>>8))
+	}
+
+	copy(p, buf)
+	n = len(buf)
+	if n > len(p) {
+		n = len(p)
+	}
+
+	return n, err
+}
+
+func (y *YMPlayer) Close() error {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+
+	if y.player != nil {
+		y.player.Destroy()
+		y.player = nil
+	}
+	return nil
+}
+
+func (y *YMPlayer) Seek(pos int64) error {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+	y.position = pos
+	return nil
+}
+
+func (y *YMPlayer) Info() Info {
+	info := y.player.GetInfo()
+	return Info{
+		Title:      info.SongName,
+		Author:     info.SongAuthor,
+		DurationMs: int64(info.MusicTimeInMs),
+	}
+}
+
+func (y *YMPlayer) SetVolume(v float64) {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+	y.volume = v
+}
+
+func (y *YMPlayer) SetLoop(loop bool) {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+	y.loop = loop
+	y.player.SetLoopMode(loop)
+}
+
+func (y *YMPlayer) Position() int64 {
+	y.mutex.Lock()
+	defer y.mutex.Unlock()
+	return y.position
+}
+
+func (y *YMPlayer) ChannelCount() int { return 3 }
+
+// SetChannelMute and SetChannelSolo are no-ops for YMPlayer: stsound only
+// exposes read access to the AY-3-8910 registers, not a way to write them
+// back or silence a voice, so per-voice muting can't be done at this layer.
+// ModPlayer owns its own mixer and supports both for real.
+func (y *YMPlayer) SetChannelMute(channel int, muted bool) {}
+
+func (y *YMPlayer) SetChannelSolo(channel int, soloed bool) {}
@@ -0,0 +1,40 @@
+// Package demo turns the intro into a small scene-graph engine: parts of
+// the demo implement Scene, and a Timeline triggers them at specific music
+// offsets, cross-fading or wiping between whichever scene is current and
+// whichever comes next.
+package demo
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// Scene is one self-contained part of the demo (the IK+ intro, a starfield,
+// a copper-bar showcase, ...). Update receives the delta time in seconds
+// since the previous call so scenes can animate independently of the host's
+// tick rate.
+type Scene interface {
+	Enter()
+	Update(dt float64)
+	Draw(screen *ebiten.Image)
+	Exit()
+}
+
+// Factory constructs a fresh Scene instance. Factories are typically
+// closures over whatever shared assets/state the scene needs.
+type Factory func() Scene
+
+var registry = map[string]Factory{}
+
+// Register makes a scene factory available to Timeline cues under name.
+// The host typically calls this once per scene from NewGame, since most
+// factories close over the *Game the scene needs to read shared state from.
+func Register(name string, factory Factory) {
+	registry[name] = factory
+}
+
+// New constructs the named scene, or nil if nothing registered under name.
+func New(name string) Scene {
+	factory, ok := registry[name]
+	if !ok {
+		return nil
+	}
+	return factory()
+}
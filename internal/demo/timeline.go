@@ -0,0 +1,130 @@
+package demo
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// Cue triggers a registered scene once the music reaches AtMs, replacing
+// whatever scene is current using Transition.
+type Cue struct {
+	Scene      string
+	AtMs       int64
+	Transition Transition
+}
+
+// Timeline sequences Scenes against a music position, so a score's layout
+// (not the code) decides when each part of the demo runs.
+type Timeline struct {
+	cues    []Cue
+	nextCue int
+
+	current     Scene
+	currentName string
+
+	previous          Scene
+	transition        Transition
+	transitionElapsed float64
+	transitionTotal   float64
+
+	prevSnapshot *ebiten.Image
+	curSnapshot  *ebiten.Image
+}
+
+// NewTimeline builds a Timeline from cues, which must be sorted by AtMs.
+func NewTimeline(cues []Cue) *Timeline {
+	return &Timeline{cues: cues}
+}
+
+// CurrentScene returns the name of the cue currently playing, or "" before
+// the first cue fires.
+func (t *Timeline) CurrentScene() string { return t.currentName }
+
+// Update advances the timeline to positionMs (milliseconds into the score)
+// and steps whichever scene(s) are active by dt seconds.
+func (t *Timeline) Update(positionMs int64, dt float64) {
+	for t.nextCue < len(t.cues) && positionMs >= t.cues[t.nextCue].AtMs {
+		t.switchTo(t.cues[t.nextCue])
+		t.nextCue++
+	}
+
+	if t.previous != nil {
+		t.transitionElapsed += dt * 1000
+		if t.transitionElapsed >= t.transitionTotal {
+			t.previous.Exit()
+			t.previous = nil
+		} else {
+			t.previous.Update(dt)
+		}
+	}
+
+	if t.current != nil {
+		t.current.Update(dt)
+	}
+}
+
+func (t *Timeline) switchTo(cue Cue) {
+	next := New(cue.Scene)
+	if next == nil {
+		return
+	}
+
+	if t.current != nil {
+		if cue.Transition.Kind == Cut || cue.Transition.DurationMs <= 0 {
+			// A cue can land while an earlier Fade/Wipe is still in flight;
+			// resolve it now so Update/Draw don't keep stepping and
+			// blending a previous scene against stale transition timing.
+			if t.previous != nil {
+				t.previous.Exit()
+				t.previous = nil
+				t.transitionTotal = 0
+			}
+			t.current.Exit()
+		} else {
+			if t.previous != nil {
+				t.previous.Exit()
+			}
+			t.previous = t.current
+			t.transition = cue.Transition
+			t.transitionElapsed = 0
+			t.transitionTotal = float64(cue.Transition.DurationMs)
+		}
+	}
+
+	t.current = next
+	t.currentName = cue.Scene
+	t.current.Enter()
+}
+
+// Draw renders the current scene, blending from the outgoing one while a
+// transition is in flight.
+func (t *Timeline) Draw(screen *ebiten.Image) {
+	if t.previous == nil || t.transitionTotal <= 0 {
+		if t.current != nil {
+			t.current.Draw(screen)
+		}
+		return
+	}
+
+	progress := t.transitionElapsed / t.transitionTotal
+	if progress > 1 {
+		progress = 1
+	}
+
+	bounds := screen.Bounds()
+	if t.prevSnapshot == nil || t.prevSnapshot.Bounds().Dx() != bounds.Dx() || t.prevSnapshot.Bounds().Dy() != bounds.Dy() {
+		t.prevSnapshot = ebiten.NewImage(bounds.Dx(), bounds.Dy())
+		t.curSnapshot = ebiten.NewImage(bounds.Dx(), bounds.Dy())
+	}
+
+	t.prevSnapshot.Clear()
+	t.previous.Draw(t.prevSnapshot)
+	t.curSnapshot.Clear()
+	if t.current != nil {
+		t.current.Draw(t.curSnapshot)
+	}
+
+	switch t.transition.Kind {
+	case Wipe:
+		drawWipe(screen, t.prevSnapshot, t.curSnapshot, progress)
+	default:
+		drawFade(screen, t.prevSnapshot, t.curSnapshot, progress)
+	}
+}
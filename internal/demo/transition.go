@@ -0,0 +1,50 @@
+package demo
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// TransitionKind selects how Timeline blends the outgoing scene into the
+// incoming one.
+type TransitionKind int
+
+const (
+	// Cut switches immediately, no blending.
+	Cut TransitionKind = iota
+	// Fade cross-dissolves the outgoing scene into the incoming one.
+	Fade
+	// Wipe reveals the incoming scene left-to-right over the outgoing one.
+	Wipe
+)
+
+// Transition describes how long and by what method a Cue replaces the
+// current scene. The zero value is an instant Cut.
+type Transition struct {
+	Kind       TransitionKind
+	DurationMs int64
+}
+
+func drawFade(screen, prev, cur *ebiten.Image, progress float64) {
+	op := &ebiten.DrawImageOptions{}
+	op.ColorM.Scale(1, 1, 1, 1-progress)
+	screen.DrawImage(prev, op)
+
+	op = &ebiten.DrawImageOptions{}
+	op.ColorM.Scale(1, 1, 1, progress)
+	screen.DrawImage(cur, op)
+}
+
+func drawWipe(screen, prev, cur *ebiten.Image, progress float64) {
+	bounds := screen.Bounds()
+	splitX := int(float64(bounds.Dx()) * progress)
+
+	screen.DrawImage(prev, &ebiten.DrawImageOptions{})
+
+	if splitX <= 0 {
+		return
+	}
+	revealed := cur.SubImage(image.Rect(0, 0, splitX, bounds.Dy())).(*ebiten.Image)
+	screen.DrawImage(revealed, &ebiten.DrawImageOptions{})
+}
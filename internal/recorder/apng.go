@@ -0,0 +1,175 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"os"
+)
+
+// apngWriter buffers every frame in memory (copying each, since callers
+// typically decode into one reused buffer) and assembles them into a single
+// APNG file on Close, since the acTL chunk needs the final frame count up
+// front and the standard library has no APNG support to build on.
+type apngWriter struct {
+	path   string
+	width  int
+	height int
+	fps    int
+	frames []*image.RGBA
+}
+
+func newAPNGWriter(path string, width, height, fps int) (*apngWriter, error) {
+	return &apngWriter{path: path, width: width, height: height, fps: fps}, nil
+}
+
+func (w *apngWriter) WriteFrame(frame *image.RGBA) error {
+	cp := image.NewRGBA(frame.Bounds())
+	copy(cp.Pix, frame.Pix)
+	w.frames = append(w.frames, cp)
+	return nil
+}
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+func (w *apngWriter) Close() error {
+	if len(w.frames) == 0 {
+		return fmt.Errorf("recorder: no frames captured, nothing to write to %s", w.path)
+	}
+
+	f, err := os.Create(w.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(pngSignature); err != nil {
+		return err
+	}
+
+	var ihdr []byte
+	idat := make([][]byte, len(w.frames))
+	for i, frame := range w.frames {
+		chunks, err := encodeFramePNG(frame)
+		if err != nil {
+			return fmt.Errorf("recorder: encode frame %d: %w", i, err)
+		}
+		if i == 0 {
+			ihdr = chunks.ihdr
+		}
+		idat[i] = chunks.idat
+	}
+
+	if err := writeChunk(f, "IHDR", ihdr); err != nil {
+		return err
+	}
+
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:4], uint32(len(w.frames)))
+	binary.BigEndian.PutUint32(actl[4:8], 0) // num_plays 0 = loop forever
+	if err := writeChunk(f, "acTL", actl); err != nil {
+		return err
+	}
+
+	fps := w.fps
+	if fps <= 0 {
+		fps = 50
+	}
+
+	var seq uint32
+	for i, data := range idat {
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:4], seq)
+		binary.BigEndian.PutUint32(fctl[4:8], uint32(w.width))
+		binary.BigEndian.PutUint32(fctl[8:12], uint32(w.height))
+		binary.BigEndian.PutUint32(fctl[12:16], 0) // x_offset
+		binary.BigEndian.PutUint32(fctl[16:20], 0) // y_offset
+		binary.BigEndian.PutUint16(fctl[20:22], 1) // delay_num
+		binary.BigEndian.PutUint16(fctl[22:24], uint16(fps))
+		fctl[24] = 0 // dispose_op: APNG_DISPOSE_OP_NONE
+		fctl[25] = 0 // blend_op: APNG_BLEND_OP_SOURCE
+		seq++
+		if err := writeChunk(f, "fcTL", fctl); err != nil {
+			return err
+		}
+
+		if i == 0 {
+			if err := writeChunk(f, "IDAT", data); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fdat := make([]byte, 4+len(data))
+		binary.BigEndian.PutUint32(fdat[0:4], seq)
+		copy(fdat[4:], data)
+		seq++
+		if err := writeChunk(f, "fdAT", fdat); err != nil {
+			return err
+		}
+	}
+
+	return writeChunk(f, "IEND", nil)
+}
+
+type framePNG struct {
+	ihdr []byte
+	idat []byte
+}
+
+// encodeFramePNG runs the frame through the standard library's PNG encoder
+// and pulls the IHDR/IDAT payloads back out, so apngWriter can repackage
+// them as APNG frames instead of standalone PNG files.
+func encodeFramePNG(frame *image.RGBA) (framePNG, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, frame); err != nil {
+		return framePNG{}, err
+	}
+
+	data := buf.Bytes()
+	if len(data) < len(pngSignature) || !bytes.Equal(data[:len(pngSignature)], pngSignature) {
+		return framePNG{}, fmt.Errorf("unexpected png header")
+	}
+	data = data[len(pngSignature):]
+
+	var out framePNG
+	for len(data) >= 12 {
+		length := binary.BigEndian.Uint32(data[0:4])
+		typ := string(data[4:8])
+		end := 8 + int(length)
+		if end+4 > len(data) {
+			break
+		}
+		payload := data[8:end]
+		switch typ {
+		case "IHDR":
+			out.ihdr = append([]byte(nil), payload...)
+		case "IDAT":
+			out.idat = append(out.idat, payload...)
+		}
+		data = data[end+4:]
+	}
+	return out, nil
+}
+
+func writeChunk(w io.Writer, typ string, payload []byte) error {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(payload)))
+	if _, err := w.Write(length[:]); err != nil {
+		return err
+	}
+
+	body := append([]byte(typ), payload...)
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+
+	var crc [4]byte
+	binary.BigEndian.PutUint32(crc[:], crc32.ChecksumIEEE(body))
+	_, err := w.Write(crc[:])
+	return err
+}
@@ -0,0 +1,192 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func solidFrame(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// rawChunk is one length/type/payload/crc chunk read back from a file
+// written by apngWriter, used to assert on its own output without pulling
+// in a third-party PNG/APNG library.
+type rawChunk struct {
+	typ     string
+	payload []byte
+}
+
+func readChunks(t *testing.T, data []byte) []rawChunk {
+	t.Helper()
+	if !bytes.Equal(data[:len(pngSignature)], pngSignature) {
+		t.Fatalf("missing PNG signature")
+	}
+	data = data[len(pngSignature):]
+
+	var chunks []rawChunk
+	for len(data) > 0 {
+		if len(data) < 12 {
+			t.Fatalf("truncated chunk header")
+		}
+		length := binary.BigEndian.Uint32(data[0:4])
+		typ := string(data[4:8])
+		payload := data[8 : 8+length]
+		gotCRC := binary.BigEndian.Uint32(data[8+length : 12+length])
+		wantCRC := crc32.ChecksumIEEE(data[4 : 8+length])
+		if gotCRC != wantCRC {
+			t.Errorf("chunk %s: crc = %08x, want %08x", typ, gotCRC, wantCRC)
+		}
+		chunks = append(chunks, rawChunk{typ: typ, payload: payload})
+		data = data[12+length:]
+	}
+	return chunks
+}
+
+func TestAPNGWriterProducesWellFormedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.png")
+	w, err := newAPNGWriter(path, 2, 2, 50)
+	if err != nil {
+		t.Fatalf("newAPNGWriter: %v", err)
+	}
+
+	colors := []color.RGBA{
+		{R: 0xff, A: 0xff},
+		{G: 0xff, A: 0xff},
+		{B: 0xff, A: 0xff},
+	}
+	for _, c := range colors {
+		if err := w.WriteFrame(solidFrame(2, 2, c)); err != nil {
+			t.Fatalf("WriteFrame: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	chunks := readChunks(t, data)
+
+	if chunks[0].typ != "IHDR" {
+		t.Fatalf("first chunk = %s, want IHDR", chunks[0].typ)
+	}
+	if len(chunks[0].payload) != 13 {
+		t.Fatalf("IHDR payload length = %d, want 13", len(chunks[0].payload))
+	}
+	gotW := binary.BigEndian.Uint32(chunks[0].payload[0:4])
+	gotH := binary.BigEndian.Uint32(chunks[0].payload[4:8])
+	if gotW != 2 || gotH != 2 {
+		t.Errorf("IHDR dims = %dx%d, want 2x2", gotW, gotH)
+	}
+
+	if chunks[1].typ != "acTL" {
+		t.Fatalf("second chunk = %s, want acTL", chunks[1].typ)
+	}
+	numFrames := binary.BigEndian.Uint32(chunks[1].payload[0:4])
+	numPlays := binary.BigEndian.Uint32(chunks[1].payload[4:8])
+	if numFrames != uint32(len(colors)) {
+		t.Errorf("acTL num_frames = %d, want %d", numFrames, len(colors))
+	}
+	if numPlays != 0 {
+		t.Errorf("acTL num_plays = %d, want 0 (loop forever)", numPlays)
+	}
+
+	if chunks[len(chunks)-1].typ != "IEND" {
+		t.Fatalf("last chunk = %s, want IEND", chunks[len(chunks)-1].typ)
+	}
+
+	// Frame 0 is fcTL + IDAT; frames 1..N-1 are fcTL + fdAT. The sequence
+	// number is a single counter shared across every fcTL and fdAT chunk,
+	// strictly increasing from 0, with IDAT carrying none at all.
+	rest := chunks[2 : len(chunks)-1]
+	wantTypes := []string{"fcTL", "IDAT", "fcTL", "fdAT", "fcTL", "fdAT"}
+	if len(rest) != len(wantTypes) {
+		t.Fatalf("got %d frame chunks, want %d: %+v", len(rest), len(wantTypes), rest)
+	}
+
+	var wantSeq uint32
+	for i, c := range rest {
+		if c.typ != wantTypes[i] {
+			t.Fatalf("chunk %d = %s, want %s", i, c.typ, wantTypes[i])
+		}
+		switch c.typ {
+		case "fcTL":
+			gotSeq := binary.BigEndian.Uint32(c.payload[0:4])
+			if gotSeq != wantSeq {
+				t.Errorf("fcTL #%d sequence_number = %d, want %d", i, gotSeq, wantSeq)
+			}
+			wantSeq++
+		case "fdAT":
+			gotSeq := binary.BigEndian.Uint32(c.payload[0:4])
+			if gotSeq != wantSeq {
+				t.Errorf("fdAT #%d sequence_number = %d, want %d", i, gotSeq, wantSeq)
+			}
+			wantSeq++
+		}
+	}
+}
+
+func TestAPNGWriterRejectsNoFrames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.png")
+	w, err := newAPNGWriter(path, 2, 2, 50)
+	if err != nil {
+		t.Fatalf("newAPNGWriter: %v", err)
+	}
+	if err := w.Close(); err == nil {
+		t.Fatal("Close with zero frames: got nil error, want one")
+	}
+}
+
+func TestWriteChunkCRC(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeChunk(&buf, "tEST", []byte("payload")); err != nil {
+		t.Fatalf("writeChunk: %v", err)
+	}
+
+	data := buf.Bytes()
+	length := binary.BigEndian.Uint32(data[0:4])
+	if length != uint32(len("payload")) {
+		t.Errorf("length = %d, want %d", length, len("payload"))
+	}
+
+	gotCRC := binary.BigEndian.Uint32(data[4+length+4 : 4+length+8])
+	wantCRC := crc32.ChecksumIEEE(data[4 : 4+4+length])
+	if gotCRC != wantCRC {
+		t.Errorf("crc = %08x, want %08x", gotCRC, wantCRC)
+	}
+}
+
+func TestEncodeFramePNGRoundTrips(t *testing.T) {
+	frame := solidFrame(4, 3, color.RGBA{R: 10, G: 20, B: 30, A: 255})
+	chunks, err := encodeFramePNG(frame)
+	if err != nil {
+		t.Fatalf("encodeFramePNG: %v", err)
+	}
+	if len(chunks.ihdr) != 13 {
+		t.Fatalf("ihdr length = %d, want 13", len(chunks.ihdr))
+	}
+	if len(chunks.idat) == 0 {
+		t.Fatal("idat payload is empty")
+	}
+
+	gotW := binary.BigEndian.Uint32(chunks.ihdr[0:4])
+	gotH := binary.BigEndian.Uint32(chunks.ihdr[4:8])
+	if gotW != 4 || gotH != 3 {
+		t.Errorf("ihdr dims = %dx%d, want 4x3", gotW, gotH)
+	}
+}
@@ -0,0 +1,57 @@
+package recorder
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"os/exec"
+	"strconv"
+)
+
+// ffmpegWriter pipes raw RGBA frames into an ffmpeg process, which does the
+// actual MP4/WebM encoding. Go has no built-in video encoder, and shelling
+// out to ffmpeg is the usual way a Go program produces one.
+type ffmpegWriter struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+func newFFmpegWriter(path string, width, height, fps int) (*ffmpegWriter, error) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return nil, fmt.Errorf("recorder: ffmpeg not found on PATH, required to write %s: %w", path, err)
+	}
+
+	cmd := exec.Command("ffmpeg",
+		"-y",
+		"-f", "rawvideo",
+		"-pixel_format", "rgba",
+		"-video_size", strconv.Itoa(width)+"x"+strconv.Itoa(height),
+		"-framerate", strconv.Itoa(fps),
+		"-i", "-",
+		"-pix_fmt", "yuv420p",
+		path,
+	)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("recorder: ffmpeg stdin pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("recorder: start ffmpeg: %w", err)
+	}
+
+	return &ffmpegWriter{cmd: cmd, stdin: stdin}, nil
+}
+
+func (w *ffmpegWriter) WriteFrame(frame *image.RGBA) error {
+	_, err := w.stdin.Write(frame.Pix)
+	return err
+}
+
+func (w *ffmpegWriter) Close() error {
+	if err := w.stdin.Close(); err != nil {
+		return err
+	}
+	return w.cmd.Wait()
+}
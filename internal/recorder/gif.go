@@ -0,0 +1,42 @@
+package recorder
+
+import (
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"os"
+)
+
+// gifWriter quantizes each frame to a palette as it arrives, keeps them in
+// memory, and encodes them all at Close, since image/gif has no incremental
+// encoder.
+type gifWriter struct {
+	path  string
+	delay int // gif.GIF.Delay is in 1/100s units
+	g     gif.GIF
+}
+
+func newGIFWriter(path string, fps int) *gifWriter {
+	if fps <= 0 {
+		fps = 50
+	}
+	return &gifWriter{path: path, delay: 100 / fps}
+}
+
+func (w *gifWriter) WriteFrame(frame *image.RGBA) error {
+	paletted := image.NewPaletted(frame.Bounds(), palette.Plan9)
+	draw.FloydSteinberg.Draw(paletted, frame.Bounds(), frame, image.Point{})
+	w.g.Image = append(w.g.Image, paletted)
+	w.g.Delay = append(w.g.Delay, w.delay)
+	return nil
+}
+
+func (w *gifWriter) Close() error {
+	f, err := os.Create(w.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return gif.EncodeAll(f, &w.g)
+}
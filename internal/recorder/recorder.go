@@ -0,0 +1,34 @@
+// Package recorder turns a sequence of rendered frames into a shareable
+// clip: animated GIF (via the standard library's image/gif), APNG, or,
+// when an ffmpeg binary is on PATH, a piped MP4/WebM encode. It knows
+// nothing about Ebiten or the demo itself — callers hand it one *image.RGBA
+// per frame, in playback order.
+package recorder
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"strings"
+)
+
+// Writer accepts frames in playback order and finishes the file on Close.
+type Writer interface {
+	WriteFrame(frame *image.RGBA) error
+	Close() error
+}
+
+// Open picks a Writer for path by its extension and prepares it to receive
+// width x height frames at the given frame rate.
+func Open(path string, width, height, fps int) (Writer, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".gif":
+		return newGIFWriter(path, fps), nil
+	case ".png":
+		return newAPNGWriter(path, width, height, fps)
+	case ".mp4", ".webm":
+		return newFFmpegWriter(path, width, height, fps)
+	default:
+		return nil, fmt.Errorf("recorder: unsupported output format %q (want .gif, .png, .mp4 or .webm)", ext)
+	}
+}
@@ -0,0 +1,213 @@
+// Package scrollmarkup tokenizes a scroller's source text into a flat slice
+// of glyph/sprite operations, each stamped with the formatting in effect at
+// that point. It generalizes the old ad-hoc ^Cs0;..^Cs3; size codes (still
+// supported, for backward compatibility) into a small streaming markup
+// language: size, color, per-glyph wobble, scroll-speed override,
+// pause-for-N-frames, and inline sprite references.
+package scrollmarkup
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// OpKind distinguishes a visible glyph from an inline sprite reference.
+type OpKind int
+
+const (
+	OpGlyph OpKind = iota
+	OpSprite
+)
+
+// Style is the formatting state in effect when an Op was emitted.
+type Style struct {
+	Size       int         // legacy scroller size tier, 0..3
+	Color      color.Color // nil means "use the scroller's default tint"
+	WobbleAmp  float64     // sine amplitude, in pixels
+	WobbleFreq float64     // sine angular frequency, per frame
+	SpeedMul   float64     // scroll-speed multiplier while this Op is current
+}
+
+// Op is one visible unit of a parsed scroller: either a glyph or an inline
+// sprite, plus the Style active when it appeared in the source and how many
+// frames the scroll should hold once this Op reaches the leading edge.
+//
+// Hidden marks an Op that Rebuild has masked out of the active tier: Kind/
+// Rune/Sprite are left untouched (so measuring this Op's advance still gives
+// the original character's width, keeping tiers glyph-for-glyph aligned) but
+// a renderer should skip drawing it.
+type Op struct {
+	Kind        OpKind
+	Rune        rune
+	Sprite      string
+	Style       Style
+	PauseFrames int
+	Hidden      bool
+}
+
+// Parse tokenizes src into a flat Op slice. Style-changing codes are folded
+// into the Style of every Op that follows them rather than emitted as Ops of
+// their own:
+//
+//	^Cs0; .. ^Cs3;    legacy size code (kept for backward compatibility)
+//	^S0;   .. ^S3;    size
+//	^C#rrggbb;        color tint
+//	^W amp,freq;      per-glyph sine wobble
+//	^P n;             pause the scroll for n frames once reached
+//	^V mul;           scroll-speed multiplier
+//	^Iname;           inline sprite named "name"
+//
+// An unrecognized or malformed code is left as literal text.
+func Parse(src string) []Op {
+	ops := make([]Op, 0, len(src))
+	style := Style{SpeedMul: 1}
+	pendingPause := 0
+
+	emit := func(op Op) {
+		op.PauseFrames = pendingPause
+		pendingPause = 0
+		ops = append(ops, op)
+	}
+
+	for i := 0; i < len(src); {
+		if src[i] != '^' {
+			emit(Op{Kind: OpGlyph, Rune: rune(src[i]), Style: style})
+			i++
+			continue
+		}
+
+		code, n := readCode(src, i)
+		if n == 0 {
+			emit(Op{Kind: OpGlyph, Rune: '^', Style: style})
+			i++
+			continue
+		}
+		body := code[2 : len(code)-1] // strip leading "^X" and trailing ";"
+
+		valid := false
+		switch {
+		case code[1] == 'C' && strings.HasPrefix(body, "s") && len(body) == 2:
+			if size, err := strconv.Atoi(body[1:]); err == nil {
+				style.Size = size
+				valid = true
+			}
+		case code[1] == 'S':
+			if size, err := strconv.Atoi(body); err == nil {
+				style.Size = size
+				valid = true
+			}
+		case code[1] == 'C' && strings.HasPrefix(body, "#"):
+			if c, ok := parseHexColor(body[1:]); ok {
+				style.Color = c
+				valid = true
+			}
+		case code[1] == 'W':
+			if amp, freq, ok := parseTwoFloats(body); ok {
+				style.WobbleAmp, style.WobbleFreq = amp, freq
+				valid = true
+			}
+		case code[1] == 'P':
+			if n, err := strconv.Atoi(strings.TrimSpace(body)); err == nil {
+				pendingPause = n
+				valid = true
+			}
+		case code[1] == 'V':
+			if mul, err := strconv.ParseFloat(strings.TrimSpace(body), 64); err == nil {
+				style.SpeedMul = mul
+				valid = true
+			}
+		case code[1] == 'I':
+			emit(Op{Kind: OpSprite, Sprite: body, Style: style})
+			valid = true
+		}
+		if !valid {
+			emit(Op{Kind: OpGlyph, Rune: '^', Style: style})
+			i++
+			continue
+		}
+
+		i += n
+	}
+
+	return ops
+}
+
+// SizeChange records the Op index at which the active scroller tier changes.
+type SizeChange struct {
+	Position int
+	Size     int
+}
+
+// SizeChanges walks ops and returns the index/tier pairs at which Style.Size
+// changes, so a host can drive auto tier-switching as the scroll advances.
+func SizeChanges(ops []Op) []SizeChange {
+	var changes []SizeChange
+	last := -1
+	for i, op := range ops {
+		if op.Style.Size != last {
+			changes = append(changes, SizeChange{Position: i, Size: op.Style.Size})
+			last = op.Style.Size
+		}
+	}
+	return changes
+}
+
+// Rebuild returns a copy of ops the same length, where every Op not
+// belonging to tier is marked Hidden instead of drawn. Kind/Rune/Sprite are
+// preserved on hidden Ops rather than replaced with a filler glyph, so their
+// advance (which varies per rune in a variable-width bitmapfont.Font) still
+// matches the original source exactly. That keeps per-tier Op slices
+// glyph-for-glyph *and* pixel-for-pixel aligned, the same way the legacy
+// code's space-padding kept its fixed-width tiers aligned.
+func Rebuild(ops []Op, tier int) []Op {
+	out := make([]Op, len(ops))
+	for i, op := range ops {
+		op.Hidden = op.Style.Size != tier
+		out[i] = op
+	}
+	return out
+}
+
+// readCode reads the markup code starting at src[i] (where src[i] == '^'),
+// returning it (including the leading '^' and trailing ';') and its length,
+// or n == 0 if src[i] isn't the start of a well-formed "^X...;" code.
+func readCode(src string, i int) (code string, n int) {
+	if i+1 >= len(src) {
+		return "", 0
+	}
+	end := strings.IndexByte(src[i:], ';')
+	if end < 2 {
+		return "", 0
+	}
+	return src[i : i+end+1], end + 1
+}
+
+func parseHexColor(hex string) (color.Color, bool) {
+	if len(hex) != 6 {
+		return nil, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, false
+	}
+	return color.RGBA{
+		R: uint8(v >> 16),
+		G: uint8(v >> 8),
+		B: uint8(v),
+		A: 0xff,
+	}, true
+}
+
+func parseTwoFloats(s string) (a, b float64, ok bool) {
+	parts := strings.SplitN(s, ",", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	av, err1 := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	bv, err2 := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return av, bv, true
+}
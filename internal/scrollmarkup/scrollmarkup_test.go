@@ -0,0 +1,125 @@
+package scrollmarkup
+
+import "testing"
+
+func TestParseLegacySizeCodes(t *testing.T) {
+	ops := Parse("^Cs0;A^Cs2;BC")
+	if len(ops) != 3 {
+		t.Fatalf("got %d ops, want 3", len(ops))
+	}
+	if ops[0].Rune != 'A' || ops[0].Style.Size != 0 {
+		t.Errorf("ops[0] = %+v, want rune A, size 0", ops[0])
+	}
+	if ops[1].Rune != 'B' || ops[1].Style.Size != 2 {
+		t.Errorf("ops[1] = %+v, want rune B, size 2", ops[1])
+	}
+	if ops[2].Rune != 'C' || ops[2].Style.Size != 2 {
+		t.Errorf("ops[2] = %+v, want rune C, size 2", ops[2])
+	}
+}
+
+func TestParseNewSizeCodeMatchesLegacy(t *testing.T) {
+	legacy := Parse("^Cs3;X")
+	modern := Parse("^S3;X")
+	if legacy[0].Style.Size != modern[0].Style.Size {
+		t.Errorf("^Cs3; and ^S3; disagree: %d vs %d", legacy[0].Style.Size, modern[0].Style.Size)
+	}
+}
+
+func TestParseColorCode(t *testing.T) {
+	ops := Parse("^C#ff8000;A")
+	r, g, b, a := ops[0].Style.Color.RGBA()
+	if r>>8 != 0xff || g>>8 != 0x80 || b>>8 != 0x00 || a>>8 != 0xff {
+		t.Errorf("got rgba %d,%d,%d,%d, want ff,80,00,ff", r>>8, g>>8, b>>8, a>>8)
+	}
+}
+
+func TestParseWobble(t *testing.T) {
+	ops := Parse("^W2.5,0.3;A")
+	if ops[0].Style.WobbleAmp != 2.5 || ops[0].Style.WobbleFreq != 0.3 {
+		t.Errorf("got wobble %v,%v, want 2.5,0.3", ops[0].Style.WobbleAmp, ops[0].Style.WobbleFreq)
+	}
+}
+
+func TestParseSpeedMul(t *testing.T) {
+	ops := Parse("A^V2;B")
+	if ops[0].Style.SpeedMul != 1 {
+		t.Errorf("ops[0].SpeedMul = %v, want default 1", ops[0].Style.SpeedMul)
+	}
+	if ops[1].Style.SpeedMul != 2 {
+		t.Errorf("ops[1].SpeedMul = %v, want 2", ops[1].Style.SpeedMul)
+	}
+}
+
+func TestParsePauseAttachesToNextOp(t *testing.T) {
+	ops := Parse("A^P5;B")
+	if ops[0].PauseFrames != 0 {
+		t.Errorf("ops[0].PauseFrames = %d, want 0 (pause precedes B, not A)", ops[0].PauseFrames)
+	}
+	if ops[1].PauseFrames != 5 {
+		t.Errorf("ops[1].PauseFrames = %d, want 5", ops[1].PauseFrames)
+	}
+}
+
+func TestParseSprite(t *testing.T) {
+	ops := Parse("A^Ilogo;B")
+	if len(ops) != 3 {
+		t.Fatalf("got %d ops, want 3 (A, sprite, B)", len(ops))
+	}
+	if ops[1].Kind != OpSprite || ops[1].Sprite != "logo" {
+		t.Errorf("ops[1] = %+v, want sprite \"logo\"", ops[1])
+	}
+}
+
+func TestParseMalformedCodeIsLiteral(t *testing.T) {
+	ops := Parse("A^ZB")
+	var runes []rune
+	for _, op := range ops {
+		runes = append(runes, op.Rune)
+	}
+	got := string(runes)
+	if got != "A^ZB" {
+		t.Errorf("got %q, want literal %q", got, "A^ZB")
+	}
+}
+
+func TestRebuildPreservesLengthAndAdvanceableFields(t *testing.T) {
+	ops := Parse("^Cs0;AB^Cs1;CD")
+	rebuilt := Rebuild(ops, 0)
+
+	if len(rebuilt) != len(ops) {
+		t.Fatalf("Rebuild changed length: got %d, want %d", len(rebuilt), len(ops))
+	}
+
+	for i, op := range rebuilt {
+		// Rune/Sprite/Kind must survive untouched so a caller measuring this
+		// Op's width (e.g. via a variable-width font) gets the original
+		// character's advance, not a filler glyph's.
+		if op.Kind != ops[i].Kind || op.Rune != ops[i].Rune || op.Sprite != ops[i].Sprite {
+			t.Errorf("rebuilt[%d] = %+v, want same Kind/Rune/Sprite as ops[%d] = %+v", i, op, i, ops[i])
+		}
+		wantHidden := ops[i].Style.Size != 0
+		if op.Hidden != wantHidden {
+			t.Errorf("rebuilt[%d].Hidden = %v, want %v", i, op.Hidden, wantHidden)
+		}
+	}
+}
+
+func TestRebuildDifferentTiersPartitionTheSameOps(t *testing.T) {
+	ops := Parse("^Cs0;A^Cs1;B^Cs2;C")
+	for tier := 0; tier < 3; tier++ {
+		rebuilt := Rebuild(ops, tier)
+		visible := 0
+		for i, op := range rebuilt {
+			if !op.Hidden {
+				visible++
+				if ops[i].Style.Size != tier {
+					t.Errorf("tier %d: rebuilt[%d] visible but belongs to tier %d", tier, i, ops[i].Style.Size)
+				}
+			}
+		}
+		if visible != 1 {
+			t.Errorf("tier %d: got %d visible ops, want exactly 1", tier, visible)
+		}
+	}
+}
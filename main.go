@@ -1,24 +1,18 @@
 package main
 
 import (
-	"bytes"
 	"embed"
-	"fmt"
-	"image"
-	"image/color"
-	_ "image/png"
-	"io"
+	"flag"
 	"log"
-	"math"
-	"math/rand"
-	"strings"
-	"sync"
+	"os"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/audio"
-	"github.com/olivierh59500/ym-player/pkg/stsound"
-	"golang.org/x/image/colornames"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"go-dom-intro/internal/chiptune"
+	"go-dom-intro/internal/demo"
 )
 
 //go:embed assets/*
@@ -27,245 +21,112 @@ var assets embed.FS
 //go:embed assets/eliminator.ym
 var ymData []byte
 
+//go:embed shaders/crt.kage
+var crtShaderSrc []byte
+
 const (
 	screenWidth  = 768
 	screenHeight = 540
 	sampleRate   = 44100
+
+	defaultCRTIntensity = 0.75
 )
 
+// Game is the top-level orchestrator: it owns audio and the CRT post-effect,
+// and delegates everything else to whichever Scene the Timeline has current.
 type Game struct {
-	starsImage     *ebiten.Image
-	logoImage      *ebiten.Image
-	scrollRast     *ebiten.Image
-	backRast       *ebiten.Image
-	font0          *ebiten.Image
-	font1          *ebiten.Image
-	font2          *ebiten.Image
-	font3          *ebiten.Image
-
-	scrollCanvas1 *ebiten.Image
-	scrollCanvas2 *ebiten.Image
-	scrollCanvas3 *ebiten.Image
-	scrollCanvas4 *ebiten.Image
-	offScroll     *ebiten.Image
-	mergeCanvas   *ebiten.Image
-
-	scrollText1 *ScrollText
-	scrollText2 *ScrollText
-	scrollText3 *ScrollText
-	scrollText4 *ScrollText
-
-	audioContext     *audio.Context
-	audioPlayer      *audio.Player
-	ymPlayer         *YMPlayer
-	musicStarted     bool
-
-	rng       *rand.Rand
-	stop      int
-	vbl       int
-	posY      float64
-	posY2     float64
-	actSize   int
-	spinc     float64
-	infStars  [8][4]float64
-
-	// Scroll text state
-	fullText    string
-	fontChanges []FontChange
-	totalGlyphs int
-}
-
-type FontChange struct {
-	position int
-	newSize  int
-}
-
-// YMPlayer wraps a YM stream for Ebiten audio.
-type YMPlayer struct {
-	player       *stsound.StSound
-	sampleRate   int
-	buffer       []int16
-	mutex        sync.Mutex
-	position     int64
-	totalSamples int64
-	loop         bool
-	volume       float64
-}
-
-func NewYMPlayer(data []byte, sampleRate int, loop bool) (*YMPlayer, error) {
-	player := stsound.CreateWithRate(sampleRate)
-
-	if err := player.LoadMemory(data); err != nil {
-		player.Destroy()
-		return nil, fmt.Errorf("failed to load YM data: %w", err)
-	}
-
-	player.SetLoopMode(loop)
-
-	info := player.GetInfo()
-	totalSamples := int64(info.MusicTimeInMs) * int64(sampleRate) / 1000
-
-	return &YMPlayer{
-		player:       player,
-		sampleRate:   sampleRate,
-		buffer:       make([]int16, 4096),
-		totalSamples: totalSamples,
-		loop:         loop,
-		volume:       0.5,
-	}, nil
-}
-
-func (y *YMPlayer) Read(p []byte) (n int, err error) {
-	y.mutex.Lock()
-	defer y.mutex.Unlock()
-
-	samplesNeeded := len(p) / 4
-	outBuffer := make([]int16, samplesNeeded*2)
-
-	processed := 0
-	for processed < samplesNeeded {
-		chunkSize := samplesNeeded - processed
-		if chunkSize > len(y.buffer) {
-			chunkSize = len(y.buffer)
-		}
-
-		if !y.player.Compute(y.buffer[:chunkSize], chunkSize) {
-			if !y.loop {
-				for i := processed * 2; i < len(outBuffer); i++ {
-					outBuffer[i] = 0
-				}
-				err = io.EOF
-				break
-			}
-		}
+	seed int64
 
-		for i := 0; i < chunkSize; i++ {
-			sample := int16(float64(y.buffer[i]) * y.volume)
-			outBuffer[(processed+i)*2] = sample
-			outBuffer[(processed+i)*2+1] = sample
-		}
+	audioContext  *audio.Context
+	audioPlayer   *audio.Player
+	musicPlayer   chiptune.Player
+	musicAnalyzer chiptune.Analyzer
+	musicStarted  bool
 
-		processed += chunkSize
-		y.position += int64(chunkSize)
-	}
+	audioFrame chiptune.ChannelFrame
 
-	buf := make([]byte, 0, len(outBuffer)*2)
-	for _, sample := range outBuffer {
-		buf = append(buf, byte(sample), byte(sample>>8))
-	}
+	crtCanvas    *ebiten.Image
+	crtEnabled   bool
+	crtIntensity float64
+	crtTime      float64
 
-	copy(p, buf)
-	n = len(buf)
-	if n > len(p) {
-		n = len(p)
-	}
+	channelMuted [4]bool
 
-	return n, err
+	timeline *demo.Timeline
 }
 
-func (y *YMPlayer) Close() error {
-	y.mutex.Lock()
-	defer y.mutex.Unlock()
+// crtShader is the compiled Kage program for the CRT post-processing pass.
+// Compiled once at package init so per-frame Draw only pays for DrawRectShader.
+var crtShader = mustCompileShader(crtShaderSrc)
 
-	if y.player != nil {
-		y.player.Destroy()
-		y.player = nil
+func mustCompileShader(src []byte) *ebiten.Shader {
+	shader, err := ebiten.NewShader(src)
+	if err != nil {
+		log.Fatalf("failed to compile crt.kage: %v", err)
 	}
-	return nil
-}
-
-type ScrollText struct {
-	canvas       *ebiten.Image
-	font         *ebiten.Image
-	text         string
-	speed        float64
-	offset       float64
-	tileW        int
-	tileH        int
-	scaleX       float64
-	scaleY       float64
-	visibleChars int
+	return shader
 }
 
-func NewGame() *Game {
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-
+// NewGame constructs a Game whose scenes seed their *rand.Rand from seed, so
+// passing the same seed (see -seed) reproduces identical star placement and
+// spawn order. This does not by itself make -record's output fully
+// deterministic: the audio-reactive visuals still depend on when the music
+// decoder's goroutine happens to land a new analysis frame, see recordingGame
+// in record.go.
+func NewGame(seed int64) *Game {
 	g := &Game{
-		scrollCanvas1: ebiten.NewImage(640, 32),
-		scrollCanvas2: ebiten.NewImage(640, 64),
-		scrollCanvas3: ebiten.NewImage(640, 128),
-		scrollCanvas4: ebiten.NewImage(640, 384),
-		offScroll:     ebiten.NewImage(640, 400),
-		mergeCanvas:   ebiten.NewImage(640, 400),
-
-		rng:           rng,
-		stop:          1,
-		posY2:         200,
-		actSize:       0,
-		spinc:         1,
+		seed:         seed,
+		crtCanvas:    ebiten.NewImage(screenWidth, screenHeight),
+		crtEnabled:   true,
+		crtIntensity: defaultCRTIntensity,
 	}
 
-	g.loadAssets()
-
-	// Initialize scroll text state
-	g.fullText = g.getFullText()
-	g.preAnalyzeFontChanges()
-
-	// Create scroll texts - all use the same source text and base font tiles
-	smallText := g.rebuildText(0, true)
-	normalText := g.rebuildText(1, false)
-	mediumText := g.rebuildText(2, false)
-	bigText := g.rebuildText(3, false)
-
-	g.scrollText1 = g.newScrollText(g.scrollCanvas1, g.font0, 40, 32, 1.0, 1.0, smallText)
-	g.scrollText2 = g.newScrollText(g.scrollCanvas2, g.font1, 40, 32, 2.0, 2.0, normalText)
-	g.scrollText3 = g.newScrollText(g.scrollCanvas3, g.font2, 40, 32, 4.0, 4.0, mediumText)
-	g.scrollText4 = g.newScrollText(g.scrollCanvas4, g.font3, 40, 32, 8.0, 12.0, bigText)
+	g.initAudio()
 
-	g.setSpeed()
+	demo.Register("ikplus", func() demo.Scene { return newIKPlusScene(g) })
+	demo.Register("starfield", func() demo.Scene { return newStarfieldScene(g) })
 
-	for i := 0; i < 8; i++ {
-		g.infStars[i][0] = math.Round(g.rng.Float64() * 9) * 64
-		g.infStars[i][1] = math.Round(g.rng.Float64() * 354)
-		g.infStars[i][2] = math.Round(g.rng.Float64()*4) + 4
-		g.infStars[i][3] = math.Round(g.rng.Float64() * 10)
-	}
+	g.timeline = demo.NewTimeline([]demo.Cue{
+		{Scene: "ikplus", AtMs: 0},
+		{Scene: "starfield", AtMs: 180000, Transition: demo.Transition{Kind: demo.Fade, DurationMs: 1500}},
+	})
 
-	g.initAudio()
 	g.startMusic()
 
 	return g
 }
 
-func (g *Game) loadAssets() {
-	g.starsImage = g.loadImage("rep_stars.png")
-	g.logoImage = g.loadImage("rep_ik+_logo.png")
-	g.scrollRast = g.loadImage("rep_ik+_rast1.png")
-	g.backRast = g.loadImage("rep_ik+_rast2.png")
-	
-	// Use only font0 and scale it for other sizes (font3 is non-uniform: 8x width, 12x height).
-	baseFontImage := g.loadImage("rep_ik+_font0.png")
-	g.font0 = baseFontImage // 1x scale
-	g.font1 = baseFontImage // Will be scaled 2x during rendering
-	g.font2 = baseFontImage // Will be scaled 4x during rendering
-	g.font3 = baseFontImage // Will be scaled 8x/12x during rendering
-}
-
 func (g *Game) initAudio() {
 	g.audioContext = audio.NewContext(sampleRate)
 
-	ym, err := NewYMPlayer(ymData, sampleRate, true)
+	name, data := "eliminator.ym", ymData
+	if *musicFlag != "" {
+		b, err := os.ReadFile(*musicFlag)
+		if err != nil {
+			log.Printf("Failed to read -music %s, falling back to embedded tune: %v", *musicFlag, err)
+		} else {
+			name, data = *musicFlag, b
+		}
+	}
+
+	music, err := chiptune.Open(name, data, sampleRate, true)
+	if err != nil && name != "eliminator.ym" {
+		log.Printf("Failed to open %s, falling back to embedded tune: %v", name, err)
+		name, data = "eliminator.ym", ymData
+		music, err = chiptune.Open(name, data, sampleRate, true)
+	}
 	if err != nil {
-		log.Printf("Failed to create YM player: %v", err)
+		log.Printf("Failed to create music player: %v", err)
 		return
 	}
-	g.ymPlayer = ym
+	g.musicPlayer = music
+	g.musicAnalyzer, _ = music.(chiptune.Analyzer)
 
-	player, err := g.audioContext.NewPlayer(ym)
+	player, err := g.audioContext.NewPlayer(music)
 	if err != nil {
 		log.Printf("Failed to create audio player: %v", err)
-		g.ymPlayer.Close()
-		g.ymPlayer = nil
+		g.musicPlayer.Close()
+		g.musicPlayer = nil
 		return
 	}
 	g.audioPlayer = player
@@ -279,508 +140,107 @@ func (g *Game) startMusic() {
 	g.musicStarted = true
 }
 
-func (g *Game) loadImage(name string) *ebiten.Image {
-	f, err := assets.Open("assets/" + name)
-	if err != nil {
-		log.Printf("Failed to open asset %s: %v", name, err)
-		// Return a blank image as fallback
-		img := ebiten.NewImage(100, 100)
-		img.Fill(colornames.Red)
-		return img
-	}
-	defer f.Close()
-	b, err := io.ReadAll(f)
-	if err != nil {
-		log.Printf("Failed to read asset %s: %v", name, err)
-		img := ebiten.NewImage(100, 100)
-		img.Fill(colornames.Red)
-		return img
-	}
-	img, _, err := image.Decode(bytes.NewReader(b))
-	if err != nil {
-		log.Printf("Failed to decode asset %s: %v", name, err)
-		img := ebiten.NewImage(100, 100)
-		img.Fill(colornames.Red)
-		return img
-	}
-	
-	// Log image dimensions
-	bounds := img.Bounds()
-//	log.Printf("Loaded asset %s: %dx%d", name, bounds.Dx(), bounds.Dy())
-	
-	// Check if image is too large for atlas (Ebiten limit is around 16384 pixels in any dimension)
-	maxSize := 4096
-	if bounds.Dx() > maxSize || bounds.Dy() > maxSize {
-		log.Printf("WARNING: Image %s is too large (%dx%d), cropping to manageable size", name, bounds.Dx(), bounds.Dy())
-		
-		// For font images, crop to a usable portion (top part contains the characters)
-		if strings.Contains(name, "font") {
-			fontWidth := bounds.Dx()
-			fontHeight := min(bounds.Dy(), maxSize) // Take first 4096 pixels of height
-			
-			// Create new image with cropped content
-			croppedImg := ebiten.NewImage(fontWidth, fontHeight)
-			sourceImg := ebiten.NewImageFromImage(img)
-			
-			// Draw the top portion of the original image
-			op := &ebiten.DrawImageOptions{}
-			srcRect := image.Rect(0, 0, fontWidth, fontHeight)
-			croppedImg.DrawImage(sourceImg.SubImage(srcRect).(*ebiten.Image), op)
-			
-			log.Printf("Cropped font %s to %dx%d", name, fontWidth, fontHeight)
-			return croppedImg
-		}
-		
-		// Create a smaller fallback image for other assets
-		fallbackImg := ebiten.NewImage(min(bounds.Dx(), maxSize), min(bounds.Dy(), maxSize))
-		fallbackImg.Fill(colornames.Gray)
-		return fallbackImg
-	}
-	
-	return ebiten.NewImageFromImage(img)
-}
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
-func (g *Game) newScrollText(canvas *ebiten.Image, font *ebiten.Image, tileW, tileH int, scaleX, scaleY float64, text string) *ScrollText {
-	st := &ScrollText{
-		canvas:       canvas,
-		font:         font,
-		text:         text,
-		tileW:        tileW,
-		tileH:        tileH,
-		scaleX:       scaleX,
-		scaleY:       scaleY,
-		offset:       float64(canvas.Bounds().Dx()),
-		visibleChars: countVisibleChars(text),
+func (g *Game) Update() error {
+	if g.musicAnalyzer != nil {
+		g.audioFrame = g.musicAnalyzer.LatestFrame()
 	}
-	return st
-}
 
-func parseControlCode(text string, i int) (int, bool) {
-	if i+4 >= len(text) || text[i] != '^' {
-		return 0, false
-	}
-	switch text[i : i+5] {
-	case "^Cs0;":
-		return 0, true
-	case "^Cs1;":
-		return 1, true
-	case "^Cs2;":
-		return 2, true
-	case "^Cs3;":
-		return 3, true
-	default:
-		return 0, false
+	if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+		g.crtEnabled = !g.crtEnabled
 	}
-}
-
-func countVisibleChars(text string) int {
-	count := 0
-	for i := 0; i < len(text); {
-		if _, ok := parseControlCode(text, i); ok {
-			i += 5
-			continue
+	if ebiten.IsKeyPressed(ebiten.KeyF4) {
+		g.crtIntensity -= 0.01
+		if g.crtIntensity < 0 {
+			g.crtIntensity = 0
 		}
-		count++
-		i++
-	}
-	return count
-}
-
-func tileIndex(char rune) int {
-	if char < ' ' || char > 'Z' {
-		return 0
 	}
-	return int(char - ' ')
-}
-
-func (g *Game) getFullText() string {
-	spc0 := "                 "
-	spc1 := "         "
-	spc2 := "     "
-	spc3 := "   "
-
-	text := "          THE UNION IS PROUD TO PRESENT YOU :" + spc0 + "^Cs2;INTERNATIONAL KARATE PLUS" + spc2 + "^Cs0;CRACKED  BY" + spc0 + "^Cs3;DOM AND CORWIN" + spc3 + "^Cs1;FROM THE" + spc1 + "^Cs3;REPLICANTS AND DMA" + spc3
-	text += "^Cs1; PRESS F1-F5 AND SEE (IF YOU CAN !!!!) AND LIST..........    A SPECIAL HI TO WILD-XEROX OR RANK-COPPER MY MASTER!!!!!ARF.... HEEEUUUU JUST A LITTLE QUESTION : WHO HAVE" + spc1
-	text += "^Cs2;BARBARIAN 2 ????????" + spc2 + "^Cs1;RRRRHHHHHAAAAAAAAAAA!!!!!! ANYBODY ????? I NEED BLOOD RRRHHAAAA!!!!! NEED HEAD !!!!! OOOUUUIIIINNNN I WEEP .. I CRY...... I RAVE , I'M DELIRIOUS I'M CAUGHT IN THE ACT-HANDED!!!!!!!" + spc1
-	text += "^Cs0;OK KO I STOP, I RESET, I BREAK, I DRINK,I FLY, I CR...-CR... HIHIHI FINALLY I SAY :" + spc0 + "^Cs3;SHEAT" + spc3 + "    ^Cs2;HEY HAVE-YOU CANAL PLUS??????????    WHAT ???????    I SAY CANAL PLUS    BORDEL !! (IN FRENCH)"
-	text += " YOU DON'T HAVE !!!! BUY THIS AND YOU WILL SEE MY MASTER : I NAME : RANK-COOPER ARF ARF HE TURN ONE'S BACK ON THE CAMERA    OOOUUFF!!!HIHI GGGGGGGGGOOOOOOOOODDDDDDDDD" + spc2 + "^Cs1;IT'S ALL FOR DAY......" + spc1
-	text += "^Cs0;REMEMBER YOU BARBARIAN 2 AND CANAL PLUS AND MY MASTER OF COURSE........ HI TO : ALL MEMBERS OF DMA(ESPECIALLY LOCKBUSTER FOR ORIGINAL), DELTA FORCE, TEX, BLADE RUNNERS, CHON-CHON, ALDO, ST-CONNEXION, THE HOBBIT BROTHERS, "
-	text += "ABC 85, THE BARBARIANS......." + spc0
-	text += "^Cs0;              "
-
-	return text
-}
-
-func (g *Game) rebuildText(size int, defaultActive bool) string {
-	var sb strings.Builder
-	active := defaultActive
-	text := g.fullText
-
-	for i := 0; i < len(text); {
-		if codeSize, ok := parseControlCode(text, i); ok {
-			active = codeSize == size
-			sb.WriteString(text[i : i+5])
-			i += 5
-			continue
-		}
-		if active {
-			sb.WriteByte(text[i])
-		} else {
-			sb.WriteByte(' ')
+	if ebiten.IsKeyPressed(ebiten.KeyF5) {
+		g.crtIntensity += 0.01
+		if g.crtIntensity > 1 {
+			g.crtIntensity = 1
 		}
-		i++
-	}
-
-	return sb.String()
-}
-
-func (g *Game) setSpeed() {
-	switch g.actSize {
-	case 0:
-		g.scrollText1.speed = 8 * g.spinc
-		g.scrollText2.speed = 16 * g.spinc
-		g.scrollText3.speed = 32 * g.spinc
-		g.scrollText4.speed = 64 * g.spinc
-	case 1:
-		g.scrollText1.speed = 4 * g.spinc
-		g.scrollText2.speed = 8 * g.spinc
-		g.scrollText3.speed = 16 * g.spinc
-		g.scrollText4.speed = 32 * g.spinc
-	case 2:
-		g.scrollText1.speed = 2 * g.spinc
-		g.scrollText2.speed = 4 * g.spinc
-		g.scrollText3.speed = 8 * g.spinc
-		g.scrollText4.speed = 16 * g.spinc
-	case 3:
-		g.scrollText1.speed = 1 * g.spinc
-		g.scrollText2.speed = 2 * g.spinc
-		g.scrollText3.speed = 4 * g.spinc
-		g.scrollText4.speed = 8 * g.spinc
-	}
-}
-
-func (st *ScrollText) draw() {
-	st.offset -= st.speed
-	if st.visibleChars == 0 {
-		return
-	}
-
-	scaledTileW := float64(st.tileW) * st.scaleX
-	totalWidth := float64(st.visibleChars) * scaledTileW
-	if totalWidth > 0 && st.offset <= -totalWidth {
-		st.offset += totalWidth + float64(st.canvas.Bounds().Dx())
-	}
-
-	st.drawAtOffset(st.offset)
-}
-
-func (st *ScrollText) drawAt(offset float64) {
-	st.offset = offset
-	if st.visibleChars == 0 {
-		return
 	}
-	st.drawAtOffset(st.offset)
-}
-
-func (st *ScrollText) drawAtOffset(offset float64) {
-	st.canvas.Clear() // Clear to transparent
-
-	scaledTileW := float64(st.tileW) * st.scaleX
-	x := offset
-	i := 0
-	for x < float64(st.canvas.Bounds().Dx()) {
-		if i >= len(st.text) {
-			break
-		}
-		if _, ok := parseControlCode(st.text, i); ok {
-			i += 5
-			continue
-		}
+	g.crtTime += 1.0 / 50.0
 
-		char := rune(st.text[i])
-		tileId := tileIndex(char)
-		subRect := image.Rect(0, tileId*st.tileH, st.tileW, (tileId+1)*st.tileH)
-		if subRect.Max.X <= st.font.Bounds().Dx() && subRect.Max.Y <= st.font.Bounds().Dy() {
-			sub := st.font.SubImage(subRect).(*ebiten.Image)
-			op := &ebiten.DrawImageOptions{}
-			// Apply scaling
-			op.GeoM.Scale(st.scaleX, st.scaleY)
-			op.GeoM.Translate(x, 0)
-			op.Filter = ebiten.FilterNearest
-			st.canvas.DrawImage(sub, op)
+	// Number keys 1-4 toggle mute on the matching music channel, for
+	// auditioning individual voices; keys beyond the backend's ChannelCount
+	// are ignored (YM exposes 3, MOD 4). YMPlayer accepts the call but
+	// cannot silence a voice, so only MOD playback is audibly affected.
+	if g.musicPlayer != nil {
+		for ch, key := range channelMuteKeys {
+			if ch >= g.musicPlayer.ChannelCount() {
+				break
+			}
+			if inpututil.IsKeyJustPressed(key) {
+				g.channelMuted[ch] = !g.channelMuted[ch]
+				g.musicPlayer.SetChannelMute(ch, g.channelMuted[ch])
+			}
 		}
-		x += scaledTileW
-		i++
 	}
-}
 
-func (g *Game) preAnalyzeFontChanges() {
-	g.fontChanges = g.fontChanges[:0]
-	glyphPos := 0
-	for i := 0; i < len(g.fullText); {
-		if size, ok := parseControlCode(g.fullText, i); ok {
-			g.fontChanges = append(g.fontChanges, FontChange{glyphPos, size})
-			i += 5
-			continue
-		}
-		glyphPos++
-		i++
+	dt := 1.0 / float64(ebiten.TPS())
+	var positionMs int64
+	if g.musicPlayer != nil {
+		positionMs = g.musicPlayer.Position() * 1000 / sampleRate
 	}
-	g.totalGlyphs = glyphPos
+	g.timeline.Update(positionMs, dt)
+
+	return nil
 }
 
-func (g *Game) updateActSizeFromScroll() {
-	if g.totalGlyphs == 0 {
+func (g *Game) Draw(screen *ebiten.Image) {
+	if !g.crtEnabled {
+		g.timeline.Draw(screen)
 		return
 	}
 
-	st := g.scrollText1
-	switch g.actSize {
-	case 1:
-		st = g.scrollText2
-	case 2:
-		st = g.scrollText3
-	case 3:
-		st = g.scrollText4
-	}
-	if st == nil {
-		return
-	}
-	tileW := float64(st.tileW) * st.scaleX
-	if tileW <= 0 {
-		return
-	}
-	leftGlyph := int(math.Floor(-st.offset / tileW))
-	if leftGlyph < 0 {
-		leftGlyph = 0
-	}
-	visibleGlyphs := int(math.Ceil(float64(st.canvas.Bounds().Dx())/tileW)) + 1
-	glyphPos := leftGlyph + visibleGlyphs
-	if glyphPos >= g.totalGlyphs {
-		glyphPos = g.totalGlyphs - 1
-	}
+	g.timeline.Draw(g.crtCanvas)
 
-	size := 0
-	for _, change := range g.fontChanges {
-		if change.position <= glyphPos {
-			size = change.newSize
-		} else {
-			break
-		}
-	}
-	if size != g.actSize {
-		g.actSize = size
-		g.setSpeed()
+	op := &ebiten.DrawRectShaderOptions{}
+	op.Images[0] = g.crtCanvas
+	op.Uniforms = map[string]interface{}{
+		"Time":             float32(g.crtTime),
+		"ScanlineStrength": float32(0.25),
+		"Curvature":        float32(0.06),
+		"Intensity":        float32(g.crtIntensity),
 	}
+	screen.DrawRectShader(screenWidth, screenHeight, crtShader, op)
 }
 
-func (g *Game) Update() error {
-	if ebiten.IsKeyPressed(ebiten.KeyF1) {
-		if g.spinc < 4 && g.spinc >= 1 {
-			g.spinc++
-		} else if g.spinc == 0.5 {
-			g.spinc = 1
-		} else if g.spinc == 0.25 {
-			g.spinc = 0.5
-		}
-		g.setSpeed()
-	}
-	if ebiten.IsKeyPressed(ebiten.KeyF2) {
-		if g.spinc > 1 {
-			g.spinc--
-		} else if g.spinc == 0.5 {
-			g.spinc = 0.25
-		} else if g.spinc == 1 {
-			g.spinc = 0.5
-		}
-		g.setSpeed()
-	}
-
-	g.vbl++
-
-	if g.vbl%2 == 0 {
-		g.posY += 4
-		if g.posY >= 654 {
-			g.posY = 0
-		}
-		g.posY2 -= 2
-		if g.posY2 <= 0 {
-			g.posY2 = 200
-		}
-	}
-
-	for i := 0; i < 8; i++ {
-		g.infStars[i][3] += 1 / g.infStars[i][2]
-		if g.infStars[i][3] >= 9 {
-			g.infStars[i][0] = math.Round(g.rng.Float64() * 9) * 64
-			g.infStars[i][1] = math.Round(g.rng.Float64() * 354)
-			g.infStars[i][2] = math.Round(g.rng.Float64()*4) + 4
-			g.infStars[i][3] = 0
-		}
-	}
-
-	if g.scrollText1 != nil {
-		g.scrollText1.draw()
-		baseOffset := g.scrollText1.offset
-		baseWidth := float64(g.scrollText1.canvas.Bounds().Dx())
-		if g.scrollText2 != nil {
-			g.scrollText2.drawAt(baseOffset*g.scrollText2.scaleX + (1-g.scrollText2.scaleX)*baseWidth)
-		}
-		if g.scrollText3 != nil {
-			g.scrollText3.drawAt(baseOffset*g.scrollText3.scaleX + (1-g.scrollText3.scaleX)*baseWidth)
-		}
-		if g.scrollText4 != nil {
-			g.scrollText4.drawAt(baseOffset*g.scrollText4.scaleX + (1-g.scrollText4.scaleX)*baseWidth)
-		}
-	}
-	g.updateActSizeFromScroll()
-
-	return nil
+func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return screenWidth, screenHeight
 }
 
-func (g *Game) Draw(screen *ebiten.Image) {
-	if g.stop > 0 {
-		screen.Fill(colornames.Black)
-
-		if g.backRast != nil {
-			for j := 0; j < 11; j++ {
-				sy := int(g.posY) + j*4
-				g.drawPart(screen, g.backRast, 0, 60+2+j*36, 0, sy, 1, 36, 1, 0, 768, 1)
-			}
-		}
-
-		if g.mergeCanvas != nil {
-			g.mergeCanvas.Fill(color.Transparent)
-		}
-
-		if g.scrollRast != nil {
-			g.drawPart(g.mergeCanvas, g.scrollRast, 0, int(g.posY2)-200, 0, 0, 2, 200, 1, 0, 320, 1)
-			g.drawPart(g.mergeCanvas, g.scrollRast, 0, int(g.posY2), 0, 0, 2, 200, 1, 0, 320, 1)
-			g.drawPart(g.mergeCanvas, g.scrollRast, 0, int(g.posY2)+200, 0, 0, 2, 200, 1, 0, 320, 1)
-		}
-
-		if g.offScroll != nil {
-			g.offScroll.Fill(color.Transparent)
-		}
-
-		switch g.actSize {
-		case 0:
-			if g.scrollCanvas1 != nil {
-				for j := 0; j < 11; j++ {
-					g.drawPart(g.offScroll, g.scrollCanvas1, 0, 2+j*36, 0, 0, 640, 32, 1, 0, 1, 1)
-				}
-			}
-		case 1:
-			if g.scrollCanvas2 != nil {
-				for j := 0; j < 6; j++ {
-					g.drawPart(g.offScroll, g.scrollCanvas2, 0, 2+j*66, 0, 0, 640, 64, 1, 0, 1, 1)
-				}
-			}
-		case 2:
-			if g.scrollCanvas3 != nil {
-				g.drawPart(g.offScroll, g.scrollCanvas3, 0, 0, 0, 0, 640, 128, 1, 0, 1, 1)
-				g.drawPart(g.offScroll, g.scrollCanvas3, 0, 134, 0, 0, 640, 128, 1, 0, 1, 1)
-				g.drawPart(g.offScroll, g.scrollCanvas3, 0, 268, 0, 0, 640, 128, 1, 0, 1, 1)
-			}
-		case 3:
-			if g.scrollCanvas4 != nil {
-				g.drawPart(g.offScroll, g.scrollCanvas4, 0, 4, 0, 0, 640, 384, 1, 0, 1, 1)
-			}
-		}
+// channelMuteKeys maps number keys 1-4 to music channel indices 0-3.
+var channelMuteKeys = []ebiten.Key{ebiten.Key1, ebiten.Key2, ebiten.Key3, ebiten.Key4}
 
-		if g.mergeCanvas != nil && g.offScroll != nil {
-			op := &ebiten.DrawImageOptions{}
-			op.CompositeMode = ebiten.CompositeModeDestinationIn
-			op.GeoM.Translate(0, 2)
-			g.mergeCanvas.DrawImage(g.offScroll, op)
-			if g.mergeCanvas.Bounds().Dy() >= 2 {
-				top := g.mergeCanvas.SubImage(image.Rect(0, 0, g.mergeCanvas.Bounds().Dx(), 2)).(*ebiten.Image)
-				top.Clear()
-			}
-		}
+var (
+	musicFlag = flag.String("music", "", "path to a .ym/.mod tune to play instead of the embedded default")
 
-		if g.mergeCanvas != nil {
-			op2 := &ebiten.DrawImageOptions{}
-			op2.GeoM.Translate(64, 60)
-			screen.DrawImage(g.mergeCanvas, op2)
-		}
+	recordFlag = flag.String("record", "", "record -frames frames to this file, then exit (the game window still opens while recording); format is inferred from the extension (.gif, .png for APNG, .mp4/.webm via ffmpeg)")
+	framesFlag = flag.Int("frames", 0, "number of frames to capture, required with -record")
+	fpsFlag    = flag.Int("fps", 50, "frame rate for -record, used both for playback speed and the fixed Update/Draw timestep")
+	seedFlag   = flag.Int64("seed", 0, "RNG seed for star placement; 0 picks one from the current time. Set explicitly to make -record's star placement reproducible (audio-reactive visuals are not seed-deterministic, see recordingGame in record.go)")
+)
 
-		if g.logoImage != nil {
-			opLogo := &ebiten.DrawImageOptions{}
-			opLogo.GeoM.Translate(64, 60+36)
-			screen.DrawImage(g.logoImage, opLogo)
-		}
+func main() {
+	flag.Parse()
 
-		if g.starsImage != nil {
-			for i := 0; i < 8; i++ {
-				tile := int(math.Round(g.infStars[i][3]))
-				g.drawTile(screen, g.starsImage, tile, 64+int(g.infStars[i][0]), 60+int(g.infStars[i][1]), 64, 46, 1, 0, 1, 1)
-			}
-		}
+	seed := *seedFlag
+	if seed == 0 {
+		seed = time.Now().UnixNano()
 	}
-}
 
-func (g *Game) drawPart(dest *ebiten.Image, src *ebiten.Image, dx, dy, sx, sy, sw, sh, param8, param9, tileX, tileY int) {
-	if src == nil || dest == nil {
-		return
-	}
-	for jy := 0; jy < tileY; jy++ {
-		for jx := 0; jx < tileX; jx++ {
-			subRect := image.Rect(sx, sy, sx+sw, sy+sh)
-			if subRect.Max.X <= src.Bounds().Dx() && subRect.Max.Y <= src.Bounds().Dy() {
-				sub := src.SubImage(subRect).(*ebiten.Image)
-				op := &ebiten.DrawImageOptions{}
-				op.GeoM.Translate(float64(dx+jx*sw), float64(dy+jy*sh))
-				dest.DrawImage(sub, op)
-			}
+	if *recordFlag != "" {
+		if err := runRecording(*recordFlag, *framesFlag, *fpsFlag, NewGame(seed)); err != nil {
+			log.Fatal(err)
 		}
-	}
-}
-
-func (g *Game) drawTile(dest *ebiten.Image, src *ebiten.Image, tile int, dx, dy, tileW, tileH int, scale float64, rot float64, flipH, flipV int) {
-	if src == nil || dest == nil {
 		return
 	}
-	cols := src.Bounds().Dx() / tileW
-	if cols == 0 {
-		return
-	}
-	row := tile / cols
-	col := tile % cols
-	subRect := image.Rect(col*tileW, row*tileH, (col+1)*tileW, (row+1)*tileH)
-	if subRect.Max.X <= src.Bounds().Dx() && subRect.Max.Y <= src.Bounds().Dy() {
-		sub := src.SubImage(subRect).(*ebiten.Image)
-		op := &ebiten.DrawImageOptions{}
-		if flipH == -1 {
-			op.GeoM.Scale(-1, 1)
-			op.GeoM.Translate(float64(tileW), 0)
-		}
-		if flipV == -1 {
-			op.GeoM.Scale(1, -1)
-			op.GeoM.Translate(0, float64(tileH))
-		}
-		op.GeoM.Scale(scale, scale)
-		op.GeoM.Rotate(rot * math.Pi / 180)
-		op.GeoM.Translate(float64(dx), float64(dy))
-		dest.DrawImage(sub, op)
-	}
-}
 
-func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return screenWidth, screenHeight
-}
-
-func main() {
 	ebiten.SetWindowSize(screenWidth, screenHeight)
 	ebiten.SetWindowTitle("Remake of the \"Dom intro\" in Golang + Ebiten")
-	if err := ebiten.RunGame(NewGame()); err != nil {
+	if err := ebiten.RunGame(NewGame(seed)); err != nil {
 		log.Fatal(err)
 	}
 }
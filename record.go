@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"go-dom-intro/internal/recorder"
+)
+
+// errRecordingDone signals recordingGame.Update has captured every requested
+// frame; runRecording treats it as success rather than a real failure.
+var errRecordingDone = errors.New("recording: done")
+
+// recordingGame drives game at a fixed timestep (1/fps seconds of simulated
+// time per tick, independent of wall-clock speed) and writes every Draw's
+// pixels to w.
+//
+// -seed only pins the RNG-driven parts (star placement, spawn order): the
+// audio-reactive ones (scroll speed, star spawn threshold, palette tint) read
+// Game.audioFrame, which chiptune's real-time Read() goroutine publishes on
+// its own schedule, not from playback position. Two -record runs with the
+// same -seed can still diverge on those, since the exact tick a new
+// ChannelFrame lands on relative to a fixed-timestep Update is down to OS
+// scheduling. Making those deterministic too would mean decoding audio
+// in lockstep with Update instead of on a free-running goroutine.
+type recordingGame struct {
+	game    *Game
+	w       recorder.Writer
+	frames  int
+	written int
+	pix     *image.RGBA
+}
+
+func newRecordingGame(game *Game, w recorder.Writer, frames int) *recordingGame {
+	return &recordingGame{
+		game:   game,
+		w:      w,
+		frames: frames,
+		pix:    image.NewRGBA(image.Rect(0, 0, screenWidth, screenHeight)),
+	}
+}
+
+func (r *recordingGame) Update() error {
+	if r.written >= r.frames {
+		return errRecordingDone
+	}
+	return r.game.Update()
+}
+
+func (r *recordingGame) Draw(screen *ebiten.Image) {
+	r.game.Draw(screen)
+	if r.written >= r.frames {
+		return
+	}
+
+	screen.ReadPixels(r.pix.Pix)
+	if err := r.w.WriteFrame(r.pix); err != nil {
+		fmt.Printf("recorder: write frame %d: %v\n", r.written, err)
+	}
+	r.written++
+}
+
+func (r *recordingGame) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return screenWidth, screenHeight
+}
+
+// runRecording plays game for frames ticks at fps and encodes each one to
+// path, inferring the container from its extension (see recorder.Open),
+// then returns. It runs through ebiten.RunGame, so a game window is open
+// while recording.
+func runRecording(path string, frames, fps int, game *Game) error {
+	if frames <= 0 {
+		return fmt.Errorf("record: -frames must be set to a positive count")
+	}
+
+	w, err := recorder.Open(path, screenWidth, screenHeight, fps)
+	if err != nil {
+		return err
+	}
+
+	ebiten.SetTPS(fps)
+	rg := newRecordingGame(game, w, frames)
+
+	if err := ebiten.RunGame(rg); err != nil && !errors.Is(err, errRecordingDone) {
+		w.Close()
+		return err
+	}
+
+	return w.Close()
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	_ "image/png"
+	"io"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/colornames"
+)
+
+// loadImage decodes an asset from the embedded assets.FS, falling back to a
+// solid placeholder so a missing/corrupt asset degrades visibly instead of
+// crashing the demo. Scenes share this rather than each rolling their own
+// loader.
+func loadImage(name string) *ebiten.Image {
+	f, err := assets.Open("assets/" + name)
+	if err != nil {
+		log.Printf("Failed to open asset %s: %v", name, err)
+		img := ebiten.NewImage(100, 100)
+		img.Fill(colornames.Red)
+		return img
+	}
+	defer f.Close()
+	b, err := io.ReadAll(f)
+	if err != nil {
+		log.Printf("Failed to read asset %s: %v", name, err)
+		img := ebiten.NewImage(100, 100)
+		img.Fill(colornames.Red)
+		return img
+	}
+	img, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		log.Printf("Failed to decode asset %s: %v", name, err)
+		img := ebiten.NewImage(100, 100)
+		img.Fill(colornames.Red)
+		return img
+	}
+
+	bounds := img.Bounds()
+
+	// Check if image is too large for atlas (Ebiten limit is around 16384 pixels in any dimension)
+	maxSize := 4096
+	if bounds.Dx() > maxSize || bounds.Dy() > maxSize {
+		log.Printf("WARNING: Image %s is too large (%dx%d), cropping to manageable size", name, bounds.Dx(), bounds.Dy())
+
+		// For font images, crop to a usable portion (top part contains the characters)
+		if strings.Contains(name, "font") {
+			fontWidth := bounds.Dx()
+			fontHeight := min(bounds.Dy(), maxSize) // Take first 4096 pixels of height
+
+			// Create new image with cropped content
+			croppedImg := ebiten.NewImage(fontWidth, fontHeight)
+			sourceImg := ebiten.NewImageFromImage(img)
+
+			// Draw the top portion of the original image
+			op := &ebiten.DrawImageOptions{}
+			srcRect := image.Rect(0, 0, fontWidth, fontHeight)
+			croppedImg.DrawImage(sourceImg.SubImage(srcRect).(*ebiten.Image), op)
+
+			log.Printf("Cropped font %s to %dx%d", name, fontWidth, fontHeight)
+			return croppedImg
+		}
+
+		// Create a smaller fallback image for other assets
+		fallbackImg := ebiten.NewImage(min(bounds.Dx(), maxSize), min(bounds.Dy(), maxSize))
+		fallbackImg.Fill(colornames.Gray)
+		return fallbackImg
+	}
+
+	return ebiten.NewImageFromImage(img)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func drawPart(dest *ebiten.Image, src *ebiten.Image, dx, dy, sx, sy, sw, sh, param8, param9, tileX, tileY int) {
+	drawPartTinted(dest, src, dx, dy, sx, sy, sw, sh, param8, param9, tileX, tileY, 1, 1, 1)
+}
+
+// drawPartTinted is drawPart plus a per-channel color scale, used by the
+// raster bars so their palette can shift with the music's channel volumes.
+func drawPartTinted(dest *ebiten.Image, src *ebiten.Image, dx, dy, sx, sy, sw, sh, param8, param9, tileX, tileY int, rScale, gScale, bScale float64) {
+	if src == nil || dest == nil {
+		return
+	}
+	for jy := 0; jy < tileY; jy++ {
+		for jx := 0; jx < tileX; jx++ {
+			subRect := image.Rect(sx, sy, sx+sw, sy+sh)
+			if subRect.Max.X <= src.Bounds().Dx() && subRect.Max.Y <= src.Bounds().Dy() {
+				sub := src.SubImage(subRect).(*ebiten.Image)
+				op := &ebiten.DrawImageOptions{}
+				op.GeoM.Translate(float64(dx+jx*sw), float64(dy+jy*sh))
+				op.ColorM.Scale(rScale, gScale, bScale, 1)
+				dest.DrawImage(sub, op)
+			}
+		}
+	}
+}
+
+func drawTile(dest *ebiten.Image, src *ebiten.Image, tile int, dx, dy, tileW, tileH int, scale float64, rot float64, flipH, flipV int) {
+	if src == nil || dest == nil {
+		return
+	}
+	cols := src.Bounds().Dx() / tileW
+	if cols == 0 {
+		return
+	}
+	row := tile / cols
+	col := tile % cols
+	subRect := image.Rect(col*tileW, row*tileH, (col+1)*tileW, (row+1)*tileH)
+	if subRect.Max.X <= src.Bounds().Dx() && subRect.Max.Y <= src.Bounds().Dy() {
+		sub := src.SubImage(subRect).(*ebiten.Image)
+		op := &ebiten.DrawImageOptions{}
+		if flipH == -1 {
+			op.GeoM.Scale(-1, 1)
+			op.GeoM.Translate(float64(tileW), 0)
+		}
+		if flipV == -1 {
+			op.GeoM.Scale(1, -1)
+			op.GeoM.Translate(0, float64(tileH))
+		}
+		op.GeoM.Scale(scale, scale)
+		op.GeoM.Rotate(rot * math.Pi / 180)
+		op.GeoM.Translate(float64(dx), float64(dy))
+		dest.DrawImage(sub, op)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/colornames"
+
+	"go-dom-intro/internal/demo"
+)
+
+var _ demo.Scene = (*StarfieldScene)(nil)
+
+// starCount is higher than IKPlusScene's infStars since this scene has no
+// scroller or logo competing for screen space.
+const starCount = 64
+
+// StarfieldScene is a plain copper-free starfield: just the classic
+// infinite-depth star scroll, reacting to the music's channel volumes the
+// same way IKPlusScene's infStars do.
+type StarfieldScene struct {
+	game *Game
+	rng  *rand.Rand
+
+	starsImage *ebiten.Image
+	stars      [starCount][4]float64
+}
+
+func newStarfieldScene(game *Game) *StarfieldScene {
+	s := &StarfieldScene{
+		game: game,
+		// +1 so this scene's star placement doesn't mirror IKPlusScene's
+		// when both are seeded from the same -seed in one run.
+		rng: rand.New(rand.NewSource(game.seed + 1)),
+	}
+
+	s.starsImage = loadImage("rep_stars.png")
+
+	for i := range s.stars {
+		s.stars[i][0] = math.Round(s.rng.Float64()*11) * 64
+		s.stars[i][1] = math.Round(s.rng.Float64()*9) * 46
+		s.stars[i][2] = math.Round(s.rng.Float64()*4) + 4
+		s.stars[i][3] = math.Round(s.rng.Float64() * 10)
+	}
+
+	return s
+}
+
+func (s *StarfieldScene) Enter() {}
+
+func (s *StarfieldScene) Update(dt float64) {
+	threshold := s.starSpawnThreshold()
+	for i := range s.stars {
+		s.stars[i][3] += 1 / s.stars[i][2]
+		if s.stars[i][3] >= threshold {
+			s.stars[i][0] = math.Round(s.rng.Float64()*11) * 64
+			s.stars[i][1] = math.Round(s.rng.Float64()*9) * 46
+			s.stars[i][2] = math.Round(s.rng.Float64()*4) + 4
+			s.stars[i][3] = 0
+		}
+	}
+}
+
+// starSpawnThreshold mirrors IKPlusScene's: louder music or a fresh beat
+// respawns stars sooner, so the field pulses with the tune.
+func (s *StarfieldScene) starSpawnThreshold() float64 {
+	f := s.game.audioFrame
+	sum := f.Volumes[0] + f.Volumes[1] + f.Volumes[2]
+	threshold := 9 - float64(sum)/45*4
+	if f.Beat {
+		threshold -= 2
+	}
+	if threshold < 3 {
+		threshold = 3
+	}
+	return threshold
+}
+
+func (s *StarfieldScene) Draw(screen *ebiten.Image) {
+	screen.Fill(colornames.Black)
+	if s.starsImage == nil {
+		return
+	}
+	for i := range s.stars {
+		tile := int(math.Round(s.stars[i][3]))
+		drawTile(screen, s.starsImage, tile, int(s.stars[i][0]), int(s.stars[i][1]), 64, 46, 1, 0, 1, 1)
+	}
+}
+
+func (s *StarfieldScene) Exit() {}